@@ -0,0 +1,148 @@
+package hrw
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Movement describes fraction of keys affected by the change.
+type Movement struct {
+	// Primary is the fraction of keys whose first node changed.
+	Primary float64
+	// TopN is the fraction of keys whose set of the first N nodes changed.
+	TopN float64
+}
+
+// MovementOnReweight evaluates keys from samples (object hashes) against
+// nodes with old and new weights and returns the fraction of keys whose
+// primary node and set of the first n nodes change. It allows to quantify
+// the effect of the weight change before applying it. n is clamped to
+// [0, len(nodes)], sets of zero nodes never change.
+func MovementOnReweight(nodes []uint64, oldWeights, newWeights []float64, samples []uint64, n int) Movement {
+	var m Movement
+	if len(samples) == 0 || len(nodes) == 0 {
+		return m
+	}
+	if n > len(nodes) {
+		n = len(nodes)
+	} else if n < 0 {
+		n = 0
+	}
+
+	var primary, topN int
+	for _, h := range samples {
+		a := sortByWeight(len(nodes), false, nodes, oldWeights, h, nil)
+		b := sortByWeight(len(nodes), false, nodes, newWeights, h, nil)
+		if a[0] != b[0] {
+			primary++
+		}
+		if !sameSet(nodeHashes(nodes, toUint64s(a[:n])), nodeHashes(nodes, toUint64s(b[:n]))) {
+			topN++
+		}
+	}
+
+	m.Primary = float64(primary) / float64(len(samples))
+	m.TopN = float64(topN) / float64(len(samples))
+	return m
+}
+
+// sameSet checks whether a and b contain the same distinct node hashes.
+func sameSet(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+loop:
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				continue loop
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// MovementOnChange evaluates keys from samples (object hashes) against old
+// and new node sets and returns the fraction of keys whose primary node and
+// set of the first n nodes change. Nodes are identified by their hashes,
+// so sets can have different members in any order. Weights can be nil for
+// unweighted sets. It allows capacity planners to estimate data movement
+// before adding or removing nodes, samples can be real keys or random
+// hashes from SampleHashes for Monte Carlo estimation. Negative n is
+// treated as 0, sets of zero nodes never change. An error is returned if
+// weights are not normalized or their number doesn't match the number of
+// nodes.
+func MovementOnChange(oldNodes []uint64, oldWeights []float64, newNodes []uint64, newWeights []float64, samples []uint64, n int) (Movement, error) {
+	var m Movement
+	if err := checkWeights(len(oldNodes), oldWeights); err != nil {
+		return m, fmt.Errorf("old weights: %w", err)
+	}
+	if err := checkWeights(len(newNodes), newWeights); err != nil {
+		return m, fmt.Errorf("new weights: %w", err)
+	}
+	if len(samples) == 0 || len(oldNodes) == 0 && len(newNodes) == 0 {
+		return m, nil
+	}
+	if n < 0 {
+		n = 0
+	}
+	k := n
+	if k < 1 {
+		k = 1
+	}
+
+	var primary, topN int
+	for _, h := range samples {
+		a := nodeHashes(oldNodes, TopNByWeight(oldNodes, oldWeights, h, k))
+		b := nodeHashes(newNodes, TopNByWeight(newNodes, newWeights, h, k))
+		if len(a) == 0 || len(b) == 0 || a[0] != b[0] {
+			primary++
+		}
+		if !sameSet(firstN(a, n), firstN(b, n)) {
+			topN++
+		}
+	}
+
+	m.Primary = float64(primary) / float64(len(samples))
+	m.TopN = float64(topN) / float64(len(samples))
+	return m, nil
+}
+
+// checkWeights checks that weights are normalized and there is one for
+// every node, nil weights are valid.
+func checkWeights(l int, weights []float64) error {
+	if weights == nil {
+		return nil
+	}
+	if len(weights) != l {
+		return fmt.Errorf("%d weights for %d nodes", len(weights), l)
+	}
+	return ValidateWeights(weights)
+}
+
+// SampleHashes returns count pseudo-random object hashes generated from
+// seed, so Monte Carlo estimations are reproducible.
+func SampleHashes(count int, seed int64) []uint64 {
+	r := rand.New(rand.NewSource(seed))
+	hs := make([]uint64, count)
+	for i := range hs {
+		hs[i] = r.Uint64()
+	}
+	return hs
+}
+
+func firstN(hs []uint64, n int) []uint64 {
+	if n > len(hs) {
+		n = len(hs)
+	}
+	return hs[:n]
+}
+
+func nodeHashes(nodes []uint64, ind []uint64) []uint64 {
+	res := make([]uint64, len(ind))
+	for k, i := range ind {
+		res[k] = nodes[i]
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package hrw
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovementOnReweight(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 10)
+		samples = make([]uint64, 10000)
+		weights = make([]float64, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte("node-" + strconv.Itoa(i)))
+		weights[i] = 1
+	}
+	for i := range samples {
+		samples[i] = Hash([]byte("key-" + strconv.Itoa(i)))
+	}
+
+	require.Equal(t, Movement{}, MovementOnReweight(nodes, weights, weights, samples, 3))
+
+	// node #0 loses its weight: only its keys move
+	reweighted := append([]float64{}, weights...)
+	reweighted[0] = 0
+
+	m := MovementOnReweight(nodes, weights, reweighted, samples, 3)
+	require.InDelta(t, 0.1, m.Primary, 0.01)
+	require.InDelta(t, 0.3, m.TopN, 0.02)
+
+	t.Run("matches direct evaluation", func(t *testing.T) {
+		var moved int
+		for _, h := range samples[:100] {
+			if SortByWeight(nodes, weights, h)[0] != SortByWeight(nodes, reweighted, h)[0] {
+				moved++
+			}
+		}
+		require.Equal(t, float64(moved)/100, MovementOnReweight(nodes, weights, reweighted, samples[:100], 1).Primary)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, Movement{}, MovementOnReweight(nodes, weights, reweighted, nil, 3))
+		require.Equal(t, Movement{}, MovementOnReweight(nil, nil, nil, samples, 3))
+	})
+
+	t.Run("negative n", func(t *testing.T) {
+		require.Equal(t, Movement{Primary: m.Primary}, MovementOnReweight(nodes, weights, reweighted, samples, -1))
+	})
+}
+
+func TestMovementOnChange(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 10)
+		samples = SampleHashes(10000, 1)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte("node-" + strconv.Itoa(i)))
+	}
+	movement := func(oldNodes []uint64, oldWeights []float64, newNodes []uint64, newWeights []float64, samples []uint64, n int) Movement {
+		m, err := MovementOnChange(oldNodes, oldWeights, newNodes, newWeights, samples, n)
+		require.NoError(t, err)
+		return m
+	}
+
+	require.Equal(t, samples, SampleHashes(10000, 1))
+	require.Equal(t, Movement{}, movement(nodes, nil, nodes, nil, samples, 3))
+
+	// the order of nodes doesn't matter
+	reversed := make([]uint64, len(nodes))
+	for i := range nodes {
+		reversed[len(nodes)-1-i] = nodes[i]
+	}
+	require.Equal(t, Movement{}, movement(nodes, nil, reversed, nil, samples, 3))
+
+	t.Run("add node", func(t *testing.T) {
+		added := append(append([]uint64{}, nodes...), Hash([]byte("node-new")))
+		m := movement(nodes, nil, added, nil, samples, 3)
+		require.InDelta(t, 1.0/11, m.Primary, 0.01)
+		require.InDelta(t, 3.0/11, m.TopN, 0.02)
+	})
+
+	t.Run("remove node", func(t *testing.T) {
+		m := movement(nodes, nil, nodes[1:], nil, samples, 3)
+		require.InDelta(t, 0.1, m.Primary, 0.01)
+		require.InDelta(t, 0.3, m.TopN, 0.02)
+	})
+
+	t.Run("matches reweight", func(t *testing.T) {
+		var (
+			weights    = make([]float64, len(nodes))
+			reweighted = make([]float64, len(nodes))
+		)
+		for i := range weights {
+			weights[i], reweighted[i] = 1, 1
+		}
+		reweighted[0] = 0.5
+		require.Equal(t, MovementOnReweight(nodes, weights, reweighted, samples, 3),
+			movement(nodes, weights, nodes, reweighted, samples, 3))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, Movement{}, movement(nodes, nil, nodes[1:], nil, nil, 3))
+		require.Equal(t, Movement{}, movement(nil, nil, nil, nil, samples, 3))
+		require.Equal(t, Movement{Primary: 1, TopN: 1}, movement(nil, nil, nodes, nil, samples, 3))
+		require.Equal(t, Movement{Primary: 1}, movement(nil, nil, nodes, nil, samples, 0))
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		weights := make([]float64, len(nodes))
+		for i := range weights {
+			weights[i] = 1
+		}
+
+		_, err := MovementOnChange(nodes, weights[:5], nodes, nil, samples, 3)
+		require.Error(t, err)
+		_, err = MovementOnChange(nodes, nil, nodes, append(weights, 1), samples, 3)
+		require.Error(t, err)
+
+		weights[3] = 2
+		_, err = MovementOnChange(nodes, nil, nodes, weights, samples, 3)
+		require.True(t, errors.Is(err, ErrWeightOutOfRange))
+	})
+}
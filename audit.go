@@ -0,0 +1,69 @@
+package hrw
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type (
+	// Decision describes a single selection made for the object.
+	Decision struct {
+		// Hash is the object hash.
+		Hash uint64 `json:"hash"`
+		// Nodes contains indices of selected nodes in selection order.
+		Nodes []uint64 `json:"nodes"`
+		// Epoch is the membership epoch the selection was made in.
+		Epoch uint64 `json:"epoch"`
+		// Time is the moment of the selection.
+		Time time.Time `json:"time"`
+	}
+
+	// Audit is a fixed-size ring buffer of the most recent decisions,
+	// so placement can be inspected after an incident without logging
+	// every selection. It is safe for concurrent use.
+	Audit struct {
+		mu   sync.Mutex
+		ring []Decision
+		next int
+		full bool
+	}
+)
+
+// NewAudit returns Audit keeping size last decisions.
+func NewAudit(size int) (*Audit, error) {
+	if size <= 0 {
+		return nil, errors.New("audit size must be positive")
+	}
+	return &Audit{ring: make([]Decision, size)}, nil
+}
+
+// Record stores the decision overwriting the oldest one if the buffer
+// is full. Nodes are copied.
+func (a *Audit) Record(d Decision) {
+	d.Nodes = append([]uint64(nil), d.Nodes...)
+
+	a.mu.Lock()
+	a.ring[a.next] = d
+	a.next++
+	if a.next == len(a.ring) {
+		a.next, a.full = 0, true
+	}
+	a.mu.Unlock()
+}
+
+// Dump returns copies of stored decisions from the oldest to the newest one.
+func (a *Audit) Dump() []Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var res []Decision
+	if a.full {
+		res = append(res, a.ring[a.next:]...)
+	}
+	res = append(res, a.ring[:a.next]...)
+	for i := range res {
+		res[i].Nodes = append([]uint64(nil), res[i].Nodes...)
+	}
+	return res
+}
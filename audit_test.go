@@ -0,0 +1,38 @@
+package hrw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	_, err := NewAudit(0)
+	require.Error(t, err)
+
+	a, err := NewAudit(3)
+	require.NoError(t, err)
+	require.Empty(t, a.Dump())
+
+	now := time.Unix(1600000000, 0)
+	nodes := []uint64{1, 2}
+	a.Record(Decision{Hash: 1, Nodes: nodes, Epoch: 7, Time: now})
+	nodes[0] = 100
+
+	require.Equal(t, []Decision{{Hash: 1, Nodes: []uint64{1, 2}, Epoch: 7, Time: now}}, a.Dump())
+
+	a.Dump()[0].Nodes[0] = 100
+	require.Equal(t, []uint64{1, 2}, a.Dump()[0].Nodes)
+
+	t.Run("overwrite", func(t *testing.T) {
+		for h := uint64(2); h <= 5; h++ {
+			a.Record(Decision{Hash: h})
+		}
+		var hashes []uint64
+		for _, d := range a.Dump() {
+			hashes = append(hashes, d.Hash)
+		}
+		require.Equal(t, []uint64{3, 4, 5}, hashes)
+	})
+}
@@ -0,0 +1,93 @@
+package hrw
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nspcc-dev/hrw/normalizer"
+)
+
+// SortByWeightBatch sorts nodes by distance * weight for every object hash
+// from hashes, the result contains one order per hash like SortByWeight
+// returns. Weights are normalized by norm (nil means they are normalized
+// already) and validated once for the whole batch, which is useful for
+// rebalancing and bulk assignment jobs. An error is returned if the number
+// of weights doesn't match the number of nodes.
+func SortByWeightBatch(nodes []uint64, weights []float64, norm normalizer.FloatNorm, hashes []uint64) ([][]uint64, error) {
+	if len(weights) != len(nodes) {
+		return nil, fmt.Errorf("%d weights for %d nodes", len(weights), len(nodes))
+	}
+	if norm != nil {
+		weights = normalizer.Apply(norm, weights)
+	}
+	if err := ValidateWeights(weights); err != nil {
+		return nil, err
+	}
+
+	var (
+		res     = make([][]uint64, len(hashes))
+		uniform = allSameF64(weights)
+		dist    = make([]uint64, len(nodes))
+	)
+	for k := range hashes {
+		for i := range nodes {
+			dist[i] = distance(nodes[i], hashes[k])
+		}
+		if uniform {
+			res[k] = toUint64s(distanceOrder(dist, nil))
+		} else {
+			res[k] = toUint64s(weightOrder(dist, weights, nil))
+		}
+	}
+	return res, nil
+}
+
+// SortBatch sorts nodes by distance for every object hash from hashes, the
+// result contains one order per hash like Sort returns. Hashes are split
+// between workers goroutines, values less than 2 mean sorting in the
+// calling goroutine.
+func SortBatch(nodes []uint64, hashes []uint64, workers int) [][]uint64 {
+	res := make([][]uint64, len(hashes))
+	if workers < 2 {
+		sortBatch(nodes, hashes, res)
+		return res
+	}
+
+	var (
+		wg    sync.WaitGroup
+		chunk = (len(hashes) + workers - 1) / workers
+	)
+	for start := 0; start < len(hashes); start += chunk {
+		end := start + chunk
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			sortBatch(nodes, hashes[start:end], res[start:end])
+		}(start, end)
+	}
+	wg.Wait()
+	return res
+}
+
+// SortSliceBatch received []T, object hashes and number of workers and
+// returns indices of slice elements in value-distance order for every hash,
+// see SortBatch. Elements are hashed once for the whole batch, nil is
+// returned for unsupported slices.
+func SortSliceBatch(slice interface{}, hashes []uint64, workers int) [][]uint64 {
+	rule := prepareRule(slice)
+	if rule == nil {
+		return nil
+	}
+	return SortBatch(rule, hashes, workers)
+}
+
+func sortBatch(nodes []uint64, hashes []uint64, res [][]uint64) {
+	dist := make([]uint64, len(nodes))
+	for k := range hashes {
+		res[k] = SortInto(make([]uint64, len(nodes)), dist, nodes, hashes[k])
+	}
+}
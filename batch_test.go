@@ -0,0 +1,82 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/nspcc-dev/hrw/normalizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortByWeightBatch(t *testing.T) {
+	var (
+		nodes  = []uint64{1, 2, 3, 4, 5, 6}
+		raw    = []float64{10, 3, 10, 2, 7, 2}
+		hashes = make([]uint64, 50)
+		key    = make([]byte, 8)
+	)
+	for i := range hashes {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		hashes[i] = Hash(key)
+	}
+
+	res, err := SortByWeightBatch(nodes, raw, normalizer.AutoMax(raw), hashes)
+	require.NoError(t, err)
+	require.Len(t, res, len(hashes))
+
+	weights := normalizer.Apply(normalizer.AutoMax(raw), raw)
+	for i := range hashes {
+		require.Equal(t, SortByWeight(nodes, weights, hashes[i]), res[i])
+	}
+
+	t.Run("normalized", func(t *testing.T) {
+		actual, err := SortByWeightBatch(nodes, weights, nil, hashes)
+		require.NoError(t, err)
+		require.Equal(t, res, actual)
+	})
+
+	t.Run("uniform", func(t *testing.T) {
+		actual, err := SortByWeightBatch(nodes, []float64{1, 1, 1, 1, 1, 1}, nil, hashes[:1])
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, hashes[0]), actual[0])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := SortByWeightBatch(nodes, raw, nil, hashes)
+		require.Error(t, err)
+	})
+
+	t.Run("weights length mismatch", func(t *testing.T) {
+		_, err := SortByWeightBatch(nodes, weights[:5], nil, hashes)
+		require.Error(t, err)
+		_, err = SortByWeightBatch(nodes, append(weights, 1), nil, hashes)
+		require.Error(t, err)
+		_, err = SortByWeightBatch(nodes, raw[:5], normalizer.AutoMax(raw[:5]), hashes)
+		require.Error(t, err)
+	})
+}
+
+func TestSortBatch(t *testing.T) {
+	var (
+		nodes  = []string{"a", "b", "c", "d", "e", "f"}
+		hashes = make([]uint64, 50)
+		key    = make([]byte, 8)
+	)
+	for i := range hashes {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		hashes[i] = Hash(key)
+	}
+	rule := SliceHashes(nodes)
+
+	for _, workers := range []int{0, 1, 3, 100} {
+		res := SortSliceBatch(nodes, hashes, workers)
+		require.Len(t, res, len(hashes))
+		for i := range hashes {
+			require.Equal(t, SortSliceIndicesByValue(nodes, hashes[i]), res[i])
+		}
+		require.Equal(t, res, SortBatch(rule, hashes, workers))
+	}
+
+	require.Empty(t, SortBatch(rule, nil, 4))
+	require.Nil(t, SortSliceBatch([]unknown{1}, hashes, 4))
+}
@@ -0,0 +1,218 @@
+package hrw
+
+import (
+	"fmt"
+	"sync"
+)
+
+type (
+	// Cluster is a set of weighted nodes identified by strings (e.g.
+	// addresses) with dynamic membership. Keys are placed on nodes by
+	// SortByWeight order of node ID hashes, so all Cluster instances with
+	// the same members and weights agree on placement. It is safe for
+	// concurrent use, zero value is an empty cluster ready to use.
+	Cluster struct {
+		mu   sync.RWMutex
+		snap *Snapshot
+	}
+
+	// Snapshot is an immutable state of the Cluster at some epoch. Every
+	// membership or weight change creates a new snapshot with the next epoch,
+	// so snapshots can be kept to find out what moved between epochs,
+	// see Diff.
+	Snapshot struct {
+		epoch   uint64
+		ids     []string
+		hashes  []uint64
+		weights []float64
+	}
+
+	// Move describes a key whose first n nodes differ between two
+	// snapshots, see Diff.
+	Move struct {
+		Key []byte
+		Old []string
+		New []string
+	}
+)
+
+// Add adds node with the given normalized weight, see ValidateWeights.
+// An error is returned if the node is already in the cluster.
+func (c *Cluster) Add(id string, weight float64) error {
+	if err := ValidateWeights([]float64{weight}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur := c.current()
+	if cur.index(id) >= 0 {
+		return fmt.Errorf("node %q is already in the cluster", id)
+	}
+	s := cur.next(len(cur.ids) + 1)
+	s.ids = append(s.ids, id)
+	s.hashes = append(s.hashes, HashString(id))
+	s.weights = append(s.weights, weight)
+	c.snap = s
+	return nil
+}
+
+// Remove removes the node from the cluster. It returns false if there
+// is no such node.
+func (c *Cluster) Remove(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur := c.current()
+	i := cur.index(id)
+	if i < 0 {
+		return false
+	}
+	s := cur.next(len(cur.ids))
+	s.ids = append(s.ids[:i], s.ids[i+1:]...)
+	s.hashes = append(s.hashes[:i], s.hashes[i+1:]...)
+	s.weights = append(s.weights[:i], s.weights[i+1:]...)
+	c.snap = s
+	return true
+}
+
+// UpdateWeight sets new normalized weight of the node. An error is
+// returned if there is no such node.
+func (c *Cluster) UpdateWeight(id string, weight float64) error {
+	if err := ValidateWeights([]float64{weight}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur := c.current()
+	i := cur.index(id)
+	if i < 0 {
+		return fmt.Errorf("node %q is not in the cluster", id)
+	}
+	s := cur.next(len(cur.ids))
+	s.weights[i] = weight
+	c.snap = s
+	return nil
+}
+
+// Snapshot returns current state of the cluster.
+func (c *Cluster) Snapshot() *Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current()
+}
+
+// Len returns the number of nodes in the cluster.
+func (c *Cluster) Len() int {
+	return c.Snapshot().Len()
+}
+
+// Get returns the node the key is placed on. It returns false if the
+// cluster is empty.
+func (c *Cluster) Get(key []byte) (string, bool) {
+	return c.Snapshot().Get(key)
+}
+
+// GetN returns the first n nodes for the key in HRW order. Negative n is
+// treated as 0, n greater than the number of nodes as the number of nodes.
+func (c *Cluster) GetN(key []byte, n int) []string {
+	return c.Snapshot().GetN(key, n)
+}
+
+// current returns the latest snapshot, c.mu must be held.
+func (c *Cluster) current() *Snapshot {
+	if c.snap == nil {
+		return new(Snapshot)
+	}
+	return c.snap
+}
+
+// Epoch returns the number of changes made to the cluster before
+// the snapshot was taken.
+func (s *Snapshot) Epoch() uint64 { return s.epoch }
+
+// Nodes returns IDs of the nodes in the order they were added.
+func (s *Snapshot) Nodes() []string {
+	return append([]string(nil), s.ids...)
+}
+
+// Len returns the number of nodes in the snapshot.
+func (s *Snapshot) Len() int { return len(s.ids) }
+
+// Get returns the node the key is placed on. It returns false if there
+// are no nodes.
+func (s *Snapshot) Get(key []byte) (string, bool) {
+	i, ok := PickByWeight(s.hashes, s.weights, Hash(key))
+	if !ok {
+		return "", false
+	}
+	return s.ids[i], true
+}
+
+// GetN returns the first n nodes for the key in HRW order, see Cluster.GetN.
+func (s *Snapshot) GetN(key []byte, n int) []string {
+	ind := TopNByWeight(s.hashes, s.weights, Hash(key), n)
+	res := make([]string, len(ind))
+	for k, i := range ind {
+		res[k] = s.ids[i]
+	}
+	return res
+}
+
+// Diff returns keys whose first n nodes (as a set, their order doesn't
+// matter) differ between from and to snapshots, i.e. keys whose replicas
+// must be migrated. Keys are returned in the given order.
+func Diff(from, to *Snapshot, keys [][]byte, n int) []Move {
+	var moves []Move
+	for _, key := range keys {
+		o, nw := from.GetN(key, n), to.GetN(key, n)
+		if !sameIDs(o, nw) {
+			moves = append(moves, Move{Key: key, Old: o, New: nw})
+		}
+	}
+	return moves
+}
+
+// sameIDs checks whether a and b contain the same distinct IDs.
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+loop:
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				continue loop
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (s *Snapshot) index(id string) int {
+	for i := range s.ids {
+		if s.ids[i] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// next returns copy of the snapshot for the next epoch with capacity
+// for l nodes.
+func (s *Snapshot) next(l int) *Snapshot {
+	res := &Snapshot{
+		epoch:   s.epoch + 1,
+		ids:     make([]string, len(s.ids), l),
+		hashes:  make([]uint64, len(s.hashes), l),
+		weights: make([]float64, len(s.weights), l),
+	}
+	copy(res.ids, s.ids)
+	copy(res.hashes, s.hashes)
+	copy(res.weights, s.weights)
+	return res
+}
@@ -0,0 +1,170 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster(t *testing.T) {
+	var (
+		c   Cluster
+		key = []byte("object")
+	)
+
+	_, ok := c.Get(key)
+	require.False(t, ok)
+	require.Empty(t, c.GetN(key, 3))
+
+	ids := []string{"a", "b", "c", "d", "e"}
+	for _, id := range ids {
+		require.NoError(t, c.Add(id, 1))
+	}
+	require.Error(t, c.Add("a", 1))
+	require.Error(t, c.Add("f", 1.5))
+	require.Equal(t, len(ids), c.Len())
+
+	expect := func(ids []string, weights []float64, n int) []string {
+		hashes := make([]uint64, len(ids))
+		for i := range ids {
+			hashes[i] = Hash([]byte(ids[i]))
+		}
+		res := make([]string, 0, n)
+		for _, i := range SortByWeight(hashes, weights, Hash(key))[:n] {
+			res = append(res, ids[i])
+		}
+		return res
+	}
+
+	require.Equal(t, expect(ids, nil, 3), c.GetN(key, 3))
+	require.Equal(t, expect(ids, nil, len(ids)), c.GetN(key, 10))
+	require.Empty(t, c.GetN(key, -1))
+	first, ok := c.Get(key)
+	require.True(t, ok)
+	require.Equal(t, c.GetN(key, 1)[0], first)
+
+	t.Run("update weight", func(t *testing.T) {
+		require.NoError(t, c.UpdateWeight("c", 0.1))
+		require.Error(t, c.UpdateWeight("c", -1))
+		require.Error(t, c.UpdateWeight("x", 0.5))
+
+		weights := []float64{1, 1, 0.1, 1, 1}
+		require.Equal(t, expect(ids, weights, len(ids)), c.GetN(key, len(ids)))
+		first, _ := c.Get(key)
+		require.Equal(t, expect(ids, weights, 1)[0], first)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.True(t, c.Remove("b"))
+		require.False(t, c.Remove("b"))
+		require.Equal(t, expect([]string{"a", "c", "d", "e"}, []float64{1, 0.1, 1, 1}, 4), c.GetN(key, 4))
+
+		// other keys don't move
+		for i := 0; i < 100; i++ {
+			k := []byte(strconv.Itoa(i))
+			before := c.GetN(k, 1)[0]
+			require.NoError(t, c.Add("b", 1))
+			after, _ := c.Get(k)
+			require.True(t, after == before || after == "b")
+			require.True(t, c.Remove("b"))
+		}
+	})
+}
+
+func TestClusterConcurrent(t *testing.T) {
+	var (
+		c   Cluster
+		wg  sync.WaitGroup
+		key = make([]byte, 8)
+	)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Add(strconv.Itoa(i), 1))
+	}
+
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			id := "extra-" + strconv.Itoa(w)
+			for i := 0; i < 100; i++ {
+				_ = c.Add(id, 0.5)
+				_ = c.UpdateWeight(id, float64(i%10)/10)
+				c.Remove(id)
+			}
+		}(w)
+	}
+	for i := 0; i < 1000; i++ {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		_, ok := c.Get(key)
+		require.True(t, ok)
+		require.Len(t, c.GetN(key, 3), 3)
+	}
+	wg.Wait()
+	require.Equal(t, 10, c.Len())
+}
+
+func TestClusterSnapshot(t *testing.T) {
+	var (
+		c    Cluster
+		keys = make([][]byte, 1000)
+	)
+	for i := range keys {
+		keys[i] = []byte("key-" + strconv.Itoa(i))
+	}
+
+	empty := c.Snapshot()
+	require.Equal(t, uint64(0), empty.Epoch())
+	require.Empty(t, empty.Nodes())
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, c.Add(id, 1))
+	}
+	s1 := c.Snapshot()
+	require.Equal(t, uint64(4), s1.Epoch())
+	require.Equal(t, []string{"a", "b", "c", "d"}, s1.Nodes())
+
+	require.NoError(t, c.Add("e", 1))
+	require.True(t, c.Remove("a"))
+	require.False(t, c.Remove("a"))
+	s2 := c.Snapshot()
+	require.Equal(t, uint64(6), s2.Epoch())
+	require.Equal(t, []string{"b", "c", "d", "e"}, s2.Nodes())
+
+	// old snapshot is not affected by later changes
+	require.Equal(t, []string{"a", "b", "c", "d"}, s1.Nodes())
+	require.Equal(t, 4, s1.Len())
+
+	require.Empty(t, Diff(s1, s1, keys, 2))
+	require.Len(t, Diff(empty, s1, keys, 2), len(keys))
+
+	moves := Diff(s1, s2, keys, 2)
+	require.NotEmpty(t, moves)
+	moved := make(map[string]bool, len(moves))
+	for _, m := range moves {
+		moved[string(m.Key)] = true
+		require.Equal(t, s1.GetN(m.Key, 2), m.Old)
+		require.Equal(t, s2.GetN(m.Key, 2), m.New)
+		require.True(t, contains(m.Old, "a") || contains(m.New, "e"), "%v -> %v", m.Old, m.New)
+	}
+	for _, key := range keys {
+		if !moved[string(key)] {
+			require.ElementsMatch(t, s1.GetN(key, 2), s2.GetN(key, 2))
+		}
+	}
+
+	// reordering of the same replicas is not a move
+	require.True(t, sameIDs([]string{"a", "b"}, []string{"b", "a"}))
+	require.False(t, sameIDs([]string{"a", "b"}, []string{"a", "c"}))
+}
+
+func contains(ids []string, id string) bool {
+	for i := range ids {
+		if ids[i] == id {
+			return true
+		}
+	}
+	return false
+}
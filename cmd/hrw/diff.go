@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nspcc-dev/hrw"
+)
+
+type (
+	// config describes placement configuration compared by diff.
+	config struct {
+		Nodes []configNode `json:"nodes"`
+	}
+
+	configNode struct {
+		ID string `json:"id"`
+		// Weight must be normalized, nodes without weight get 1.
+		Weight *float64 `json:"weight,omitempty"`
+	}
+
+	// ring contains nodes of the configuration prepared for sorting.
+	ring struct {
+		ids     []string
+		hashes  []uint64
+		weights []float64
+	}
+)
+
+func diffCmd(args []string, w io.Writer) error {
+	var (
+		fs       = flag.NewFlagSet("diff", flag.ContinueOnError)
+		oldPath  = fs.String("old", "", "JSON file with the current configuration")
+		newPath  = fs.String("new", "", "JSON file with the new configuration")
+		keysPath = fs.String("keys", "", "file with object keys, one per line")
+		replicas = fs.Int("replicas", 1, "number of replicas compared for every key")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *oldPath == "" || *newPath == "" || *keysPath == "" {
+		return errors.New("-old, -new and -keys must be specified")
+	}
+	if *replicas < 1 {
+		return errors.New("number of replicas must be positive")
+	}
+
+	oldCfg, err := readConfig(*oldPath)
+	if err != nil {
+		return err
+	}
+	newCfg, err := readConfig(*newPath)
+	if err != nil {
+		return err
+	}
+	keys, err := readNodes(*keysPath)
+	if err != nil {
+		return err
+	}
+
+	return diff(w, oldCfg, newCfg, keys, *replicas)
+}
+
+func diff(w io.Writer, oldCfg, newCfg *config, keys []string, replicas int) error {
+	var (
+		tw           = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		owners, reps int
+		oldRing      = oldCfg.ring()
+		newRing      = newCfg.ring()
+	)
+	fmt.Fprintln(tw, "KEY\tOWNER\tREPLICAS")
+	for _, key := range keys {
+		a := oldRing.place(key, replicas)
+		b := newRing.place(key, replicas)
+
+		ownerMoved := len(a) == 0 || len(b) == 0 || a[0] != b[0]
+		setChanged := !sameSet(a, b)
+		if !ownerMoved && !setChanged {
+			continue
+		}
+
+		owner := "="
+		if ownerMoved {
+			owner = first(a) + " -> " + first(b)
+			owners++
+		}
+		set := "="
+		if setChanged {
+			set = strings.Join(a, ",") + " -> " + strings.Join(b, ",")
+			reps++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, owner, set)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d of %d keys change owner, %d change replica set\n", owners, len(keys), reps)
+	return err
+}
+
+func readConfig(path string) (*config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := new(config)
+	if err := json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(cfg.Nodes))
+	for _, n := range cfg.Nodes {
+		if n.ID == "" || seen[n.ID] {
+			return nil, fmt.Errorf("%s: empty or duplicate node ID %q", path, n.ID)
+		}
+		seen[n.ID] = true
+	}
+	if err := hrw.ValidateWeights(cfg.weights()); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *config) weights() []float64 {
+	ws := make([]float64, len(c.Nodes))
+	for i, n := range c.Nodes {
+		ws[i] = hrw.NormalizedMaxWeight
+		if n.Weight != nil {
+			ws[i] = *n.Weight
+		}
+	}
+	return ws
+}
+
+func (c *config) ring() *ring {
+	r := &ring{
+		ids:     make([]string, len(c.Nodes)),
+		hashes:  make([]uint64, len(c.Nodes)),
+		weights: c.weights(),
+	}
+	for i := range c.Nodes {
+		r.ids[i] = c.Nodes[i].ID
+		r.hashes[i] = hrw.Hash([]byte(r.ids[i]))
+	}
+	return r
+}
+
+// place returns IDs of the first n nodes for the key.
+func (r *ring) place(key string, n int) []string {
+	order := hrw.SortByWeight(r.hashes, r.weights, hrw.Hash([]byte(key)))
+	if n > len(order) {
+		n = len(order)
+	}
+	res := make([]string, n)
+	for i := range res {
+		res[i] = r.ids[order[i]]
+	}
+	return res
+}
+
+func first(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	return ids[0]
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[string]bool, len(a))
+	for i := range a {
+		m[a[i]] = true
+	}
+	for i := range b {
+		if !m[b[i]] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hrw")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		oldCfg = writeFile(t, dir, "old.json", `{"nodes": [{"id": "a"}, {"id": "b"}, {"id": "c"}]}`)
+		newCfg = writeFile(t, dir, "new.json", `{"nodes": [{"id": "a"}, {"id": "b"}, {"id": "c"}, {"id": "d"}]}`)
+		keys   []string
+	)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, "key-"+strconv.Itoa(i))
+	}
+	keysPath := writeFile(t, dir, "keys", strings.Join(keys, "\n"))
+
+	t.Run("same", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, diffCmd([]string{"-old", oldCfg, "-new", oldCfg, "-keys", keysPath}, buf))
+		require.Contains(t, buf.String(), "0 of 100 keys change owner, 0 change replica set")
+	})
+
+	t.Run("new node", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, diffCmd([]string{"-old", oldCfg, "-new", newCfg, "-keys", keysPath, "-replicas", "2"}, buf))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.True(t, strings.HasPrefix(lines[0], "KEY"))
+
+		var owners int
+		for _, line := range lines[1 : len(lines)-2] {
+			fields := strings.Fields(line)
+			// keys can move only to the new node
+			if fields[1] != "=" {
+				owners++
+				require.Equal(t, "d", fields[3])
+			}
+			require.Contains(t, strings.Split(fields[len(fields)-1], ","), "d")
+		}
+		require.True(t, owners > 10 && owners < 40, "%d keys moved", owners)
+		require.Contains(t, lines[len(lines)-1], strconv.Itoa(owners)+" of 100 keys change owner")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dup := writeFile(t, dir, "dup.json", `{"nodes": [{"id": "a"}, {"id": "a"}]}`)
+		heavy := writeFile(t, dir, "heavy.json", `{"nodes": [{"id": "a", "weight": 2}]}`)
+		broken := writeFile(t, dir, "broken.json", `{"nodes": `)
+
+		for _, args := range [][]string{
+			{"-old", oldCfg, "-new", newCfg},
+			{"-old", oldCfg, "-new", dup, "-keys", keysPath},
+			{"-old", heavy, "-new", newCfg, "-keys", keysPath},
+			{"-old", oldCfg, "-new", broken, "-keys", keysPath},
+			{"-old", oldCfg, "-new", newCfg, "-keys", keysPath, "-replicas", "0"},
+		} {
+			require.Error(t, diffCmd(args, ioutil.Discard), args)
+		}
+	})
+}
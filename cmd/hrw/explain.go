@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nspcc-dev/hrw"
+)
+
+func explainCmd(args []string, w io.Writer) error {
+	var (
+		fs      = flag.NewFlagSet("explain", flag.ContinueOnError)
+		key     = fs.String("key", "", "object key")
+		nodes   = fs.String("nodes", "", "file with node IDs, one per line")
+		weights = fs.String("weights", "", "file with `<node ID> <weight>` lines, optional")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" || *nodes == "" {
+		return errors.New("both -key and -nodes must be specified")
+	}
+
+	ids, err := readNodes(*nodes)
+	if err != nil {
+		return err
+	}
+
+	var ws []float64
+	if *weights != "" {
+		m, err := readWeights(*weights)
+		if err != nil {
+			return err
+		}
+		ws = make([]float64, len(ids))
+		for i := range ids {
+			var ok bool
+			if ws[i], ok = m[ids[i]]; !ok {
+				return fmt.Errorf("missing weight for node %q", ids[i])
+			}
+		}
+		if err := hrw.ValidateWeights(ws); err != nil {
+			return err
+		}
+	}
+
+	return explain(w, *key, ids, ws)
+}
+
+func explain(w io.Writer, key string, ids []string, weights []float64) error {
+	hashes := make([]uint64, len(ids))
+	for i := range ids {
+		hashes[i] = hrw.Hash([]byte(ids[i]))
+	}
+
+	hash := hrw.Hash([]byte(key))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "key %q, hash %016x\n\n", key, hash)
+	fmt.Fprintln(tw, "RANK\tNODE\tHASH\tDISTANCE\tWEIGHT\tSCORE")
+	for k, e := range hrw.Explain(hashes, weights, hash) {
+		fmt.Fprintf(tw, "%d\t%s\t%016x\t%016x\t%g\t%.6e\n",
+			k+1, ids[e.Index], e.Hash, e.Distance, e.Weight, e.Score)
+	}
+	return tw.Flush()
+}
+
+func readNodes(path string) ([]string, error) {
+	var ids []string
+	err := readLines(path, func(line string) error {
+		ids = append(ids, line)
+		return nil
+	})
+	return ids, err
+}
+
+func readWeights(path string) (map[string]float64, error) {
+	m := make(map[string]float64)
+	err := readLines(path, func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid weight line %q", line)
+		}
+		w, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid weight line %q: %w", line, err)
+		}
+		m[fields[0]] = w
+		return nil
+	})
+	return m, err
+}
+
+// readLines calls f for every non-empty line which is not a comment.
+func readLines(path string, f func(line string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
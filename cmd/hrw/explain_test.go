@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/hrw"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, data string) string {
+	p := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(p, []byte(data), 0644))
+	return p
+}
+
+func TestExplain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hrw")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		ids     = []string{"one.example.com", "two.example.com", "three.example.com"}
+		nodes   = writeFile(t, dir, "nodes", "# servers\n"+strings.Join(ids, "\n")+"\n\n")
+		weights = writeFile(t, dir, "weights", "one.example.com 1\ntwo.example.com 0.5\nthree.example.com 0.1\n")
+		key     = "/examples/object-key"
+	)
+
+	t.Run("unweighted", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, explainCmd([]string{"-key", key, "-nodes", nodes}, buf))
+
+		expect := append([]string{}, ids...)
+		hrw.SortSliceByValue(expect, hrw.Hash([]byte(key)))
+		checkRanks(t, buf.String(), expect)
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, explainCmd([]string{"-key", key, "-nodes", nodes, "-weights", weights}, buf))
+
+		expect := append([]string{}, ids...)
+		hrw.SortSliceByWeightValue(expect, []float64{1, 0.5, 0.1}, hrw.Hash([]byte(key)))
+		checkRanks(t, buf.String(), expect)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		missing := writeFile(t, dir, "missing", "one.example.com 1\n")
+		invalid := writeFile(t, dir, "invalid", "one.example.com x\n")
+		big := writeFile(t, dir, "big", "one.example.com 10\ntwo.example.com 1\nthree.example.com 1\n")
+
+		for _, args := range [][]string{
+			{"-nodes", nodes},
+			{"-key", key},
+			{"-key", key, "-nodes", filepath.Join(dir, "none")},
+			{"-key", key, "-nodes", nodes, "-weights", missing},
+			{"-key", key, "-nodes", nodes, "-weights", invalid},
+			{"-key", key, "-nodes", nodes, "-weights", big},
+		} {
+			require.Error(t, explainCmd(args, ioutil.Discard), args)
+		}
+	})
+}
+
+func checkRanks(t *testing.T, out string, expect []string) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, len(expect)+3)
+	require.True(t, strings.HasPrefix(lines[2], "RANK"))
+	for i, line := range lines[3:] {
+		require.Equal(t, expect[i], strings.Fields(line)[1])
+	}
+}
@@ -0,0 +1,41 @@
+// hrw is a command-line tool for inspecting HRW placement.
+//
+// Usage:
+//
+//	hrw explain -key K -nodes nodes.txt [-weights weights.txt]
+//	hrw diff -old old.json -new new.json -keys keys.txt [-replicas N]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: hrw <command> [flags]
+
+Commands:
+  explain   print ranked table of nodes for the key
+  diff      print keys changing owner or replica set between two configurations
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "explain":
+		err = explainCmd(os.Args[2:], os.Stdout)
+	case "diff":
+		err = diffCmd(os.Args[2:], os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,70 @@
+// hrwsim replays churn scenarios (membership changes over time) and reports
+// key movement, replica stability and balance metrics as CSV or JSON records.
+//
+// Usage:
+//
+//	hrwsim -scenario scenario.json [-out result.csv] [-format csv|json]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	var (
+		scenario = flag.String("scenario", "", "path to JSON scenario file, stdin is used if empty")
+		out      = flag.String("out", "", "path to output file, stdout is used if empty")
+		format   = flag.String("format", formatCSV, "output format: csv or json")
+	)
+	flag.Parse()
+
+	if err := run(*scenario, *out, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, format string) (err error) {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if in != "" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	s, err := ReadScenario(r)
+	if err != nil {
+		return fmt.Errorf("can't read scenario: %w", err)
+	}
+
+	steps, err := s.Run()
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if out != "" {
+		f, cerr := os.Create(out)
+		if cerr != nil {
+			return cerr
+		}
+		// err is the named result here, so a failed flush on close is reported
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	return writeRecords(w, format, Records(steps))
+}
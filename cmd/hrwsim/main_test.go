@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hrwsim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		in  = filepath.Join(dir, "scenario.json")
+		out = filepath.Join(dir, "result.csv")
+	)
+	require.NoError(t, ioutil.WriteFile(in, []byte(testScenario), 0644))
+
+	require.NoError(t, run(in, out, formatCSV))
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte("source,time,label,metric,value\n")))
+
+	t.Run("unknown format", func(t *testing.T) {
+		require.Error(t, run(in, out, "xml"))
+
+		// output is left intact
+		actual, err := ioutil.ReadFile(out)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+	})
+}
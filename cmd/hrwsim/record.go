@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Record is a single measurement. All results are exported as a flat list
+// of records, so the same schema fits any kind of analysis and results of
+// different library versions can be compared directly.
+type Record struct {
+	// Source is the name of the tool or analysis produced the record.
+	Source string `json:"source"`
+	// Time is the scenario time the measurement relates to.
+	Time int64 `json:"time"`
+	// Label describes the measured state, e.g. events applied.
+	Label string `json:"label"`
+	// Metric is the name of the measured value.
+	Metric string `json:"metric"`
+	// Value is the measured value.
+	Value float64 `json:"value"`
+}
+
+// Output formats.
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+var recordHeader = []string{"source", "time", "label", "metric", "value"}
+
+// Records converts simulation steps into records.
+func Records(steps []Step) []Record {
+	var rs []Record
+	for _, s := range steps {
+		for _, m := range []struct {
+			name  string
+			value float64
+		}{
+			{"nodes", float64(s.Nodes)},
+			{"primary_moved", s.PrimaryMoved},
+			{"replicas_changed", s.ReplicasChanged},
+			{"replica_stability", s.ReplicaStability},
+			{"max_load", s.MaxLoad},
+		} {
+			rs = append(rs, Record{
+				Source: "hrwsim",
+				Time:   s.Time,
+				Label:  s.Events,
+				Metric: m.name,
+				Value:  m.value,
+			})
+		}
+	}
+	return rs
+}
+
+// checkFormat returns an error if records can't be written in the given format.
+func checkFormat(format string) error {
+	switch format {
+	case formatCSV, formatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeRecords writes records in the given format: CSV with a header
+// or JSON with one object per line.
+func writeRecords(w io.Writer, format string, rs []Record) error {
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(recordHeader)
+		for _, r := range rs {
+			_ = cw.Write([]string{
+				r.Source,
+				strconv.FormatInt(r.Time, 10),
+				r.Label,
+				r.Metric,
+				strconv.FormatFloat(r.Value, 'g', -1, 64),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range rs {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return checkFormat(format)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecords(t *testing.T) {
+	rs := Records([]Step{{Time: 5, Events: "join a", Nodes: 3, PrimaryMoved: 0.25, ReplicaStability: 1}})
+	require.Equal(t, []Record{
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "nodes", Value: 3},
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "primary_moved", Value: 0.25},
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "replicas_changed", Value: 0},
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "replica_stability", Value: 1},
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "max_load", Value: 0},
+	}, rs)
+}
+
+func TestWriteRecords(t *testing.T) {
+	rs := []Record{
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "nodes", Value: 3},
+		{Source: "hrwsim", Time: 5, Label: "join a", Metric: "primary_moved", Value: 0.25},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, writeRecords(buf, formatCSV, rs))
+
+		records, err := csv.NewReader(buf).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"source", "time", "label", "metric", "value"},
+			{"hrwsim", "5", "join a", "nodes", "3"},
+			{"hrwsim", "5", "join a", "primary_moved", "0.25"},
+		}, records)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, writeRecords(buf, formatJSON, rs))
+
+		var (
+			actual []Record
+			dec    = json.NewDecoder(buf)
+		)
+		for dec.More() {
+			var r Record
+			require.NoError(t, dec.Decode(&r))
+			actual = append(actual, r)
+		}
+		require.Equal(t, rs, actual)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		require.Error(t, writeRecords(bytes.NewBuffer(nil), "xml", rs))
+	})
+}
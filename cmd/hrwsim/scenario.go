@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/nspcc-dev/hrw"
+	"github.com/nspcc-dev/hrw/normalizer"
+)
+
+type (
+	// Node describes cluster member. Weight is a raw capacity value,
+	// weights of all nodes are normalized by the maximum one.
+	Node struct {
+		ID     string  `json:"id"`
+		Weight float64 `json:"weight"`
+	}
+
+	// Event changes membership at the given time. Exactly one of
+	// Join, Leave and Reweight must be set.
+	Event struct {
+		Time     int64  `json:"time"`
+		Join     *Node  `json:"join,omitempty"`
+		Leave    string `json:"leave,omitempty"`
+		Reweight *Node  `json:"reweight,omitempty"`
+	}
+
+	// Scenario is a churn experiment: initial membership, timed
+	// membership changes and key workload.
+	Scenario struct {
+		Nodes    []Node  `json:"nodes"`
+		Events   []Event `json:"events"`
+		Keys     int     `json:"keys"`
+		Replicas int     `json:"replicas"`
+	}
+
+	// Step contains metrics calculated after all events at the given time.
+	Step struct {
+		Time   int64
+		Events string
+		Nodes  int
+		// PrimaryMoved is a fraction of keys whose primary node changed.
+		PrimaryMoved float64
+		// ReplicasChanged is a fraction of keys whose replica set changed.
+		ReplicasChanged float64
+		// ReplicaStability is a fraction of replicas kept on the same nodes.
+		ReplicaStability float64
+		// MaxLoad is the maximum ratio of primary keys assigned to the node
+		// to the number of keys expected with respect to its weight.
+		MaxLoad float64
+	}
+
+	cluster struct {
+		nodes []Node
+	}
+)
+
+// ReadScenario decodes JSON scenario and validates it.
+func ReadScenario(r io.Reader) (*Scenario, error) {
+	var s Scenario
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Scenario) validate() error {
+	if s.Keys <= 0 {
+		return errors.New("number of keys must be positive")
+	} else if s.Replicas <= 0 {
+		return errors.New("number of replicas must be positive")
+	}
+	for i, e := range s.Events {
+		n := 0
+		if e.Join != nil {
+			n++
+		}
+		if e.Leave != "" {
+			n++
+		}
+		if e.Reweight != nil {
+			n++
+		}
+		if n != 1 {
+			return fmt.Errorf("event #%d: exactly one of join, leave and reweight must be set", i)
+		}
+	}
+	return nil
+}
+
+// Run replays scenario and returns metrics for the initial
+// membership and for every point in time events happened at.
+func (s *Scenario) Run() ([]Step, error) {
+	events := append([]Event{}, s.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+
+	var (
+		c    = &cluster{nodes: append([]Node{}, s.Nodes...)}
+		keys = make([]uint64, s.Keys)
+	)
+	for i := range keys {
+		keys[i] = hrw.Hash([]byte("key-" + strconv.Itoa(i)))
+	}
+
+	var (
+		prev  = c.place(keys, s.Replicas)
+		steps = []Step{c.step(0, "initial", nil, prev, len(keys))}
+	)
+
+	for i := 0; i < len(events); {
+		var (
+			t    = events[i].Time
+			desc string
+		)
+		for ; i < len(events) && events[i].Time == t; i++ {
+			d, err := c.apply(events[i])
+			if err != nil {
+				return nil, fmt.Errorf("time %d: %w", t, err)
+			}
+			if desc != "" {
+				desc += ";"
+			}
+			desc += d
+		}
+
+		cur := c.place(keys, s.Replicas)
+		steps = append(steps, c.step(t, desc, prev, cur, len(keys)))
+		prev = cur
+	}
+	return steps, nil
+}
+
+func (c *cluster) apply(e Event) (string, error) {
+	switch {
+	case e.Join != nil:
+		if c.find(e.Join.ID) >= 0 {
+			return "", fmt.Errorf("node %q already exists", e.Join.ID)
+		}
+		c.nodes = append(c.nodes, *e.Join)
+		return "join " + e.Join.ID, nil
+	case e.Reweight != nil:
+		i := c.find(e.Reweight.ID)
+		if i < 0 {
+			return "", fmt.Errorf("node %q doesn't exist", e.Reweight.ID)
+		}
+		c.nodes[i].Weight = e.Reweight.Weight
+		return "reweight " + e.Reweight.ID, nil
+	default:
+		i := c.find(e.Leave)
+		if i < 0 {
+			return "", fmt.Errorf("node %q doesn't exist", e.Leave)
+		}
+		c.nodes = append(c.nodes[:i], c.nodes[i+1:]...)
+		return "leave " + e.Leave, nil
+	}
+}
+
+func (c *cluster) find(id string) int {
+	for i := range c.nodes {
+		if c.nodes[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// place returns IDs of replica nodes for every key.
+func (c *cluster) place(keys []uint64, replicas int) [][]string {
+	var (
+		hashes  = make([]uint64, len(c.nodes))
+		weights = make([]float64, len(c.nodes))
+		result  = make([][]string, len(keys))
+	)
+	for i := range c.nodes {
+		hashes[i] = hrw.Hash([]byte(c.nodes[i].ID))
+		weights[i] = c.nodes[i].Weight
+	}
+	weights = normalizer.Apply(normalizer.AutoMax(weights), weights)
+
+	if replicas > len(c.nodes) {
+		replicas = len(c.nodes)
+	}
+	for k := range keys {
+		order := hrw.SortByWeight(hashes, weights, keys[k])
+		result[k] = make([]string, replicas)
+		for r := range result[k] {
+			result[k][r] = c.nodes[order[r]].ID
+		}
+	}
+	return result
+}
+
+func (c *cluster) step(t int64, desc string, prev, cur [][]string, keys int) Step {
+	s := Step{
+		Time:             t,
+		Events:           desc,
+		Nodes:            len(c.nodes),
+		ReplicaStability: 1,
+	}
+
+	if prev != nil {
+		var moved, changed, kept, total int
+		for k := range cur {
+			if len(prev[k]) == 0 || len(cur[k]) == 0 || prev[k][0] != cur[k][0] {
+				moved++
+			}
+
+			n := common(prev[k], cur[k])
+			if n != len(prev[k]) || n != len(cur[k]) {
+				changed++
+			}
+			kept += n
+			total += len(prev[k])
+		}
+		s.PrimaryMoved = float64(moved) / float64(keys)
+		s.ReplicasChanged = float64(changed) / float64(keys)
+		if total != 0 {
+			s.ReplicaStability = float64(kept) / float64(total)
+		}
+	}
+
+	var sum float64
+	for i := range c.nodes {
+		sum += c.nodes[i].Weight
+	}
+	if sum > 0 {
+		load := make(map[string]int, len(c.nodes))
+		for k := range cur {
+			if len(cur[k]) != 0 {
+				load[cur[k][0]]++
+			}
+		}
+		for i := range c.nodes {
+			if c.nodes[i].Weight <= 0 {
+				continue
+			}
+			expected := float64(keys) * c.nodes[i].Weight / sum
+			if l := float64(load[c.nodes[i].ID]) / expected; l > s.MaxLoad {
+				s.MaxLoad = l
+			}
+		}
+	}
+	return s
+}
+
+// common returns number of elements present in both a and b.
+func common(a, b []string) int {
+	var n int
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
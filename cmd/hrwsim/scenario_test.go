@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `{
+	"nodes": [
+		{"id": "n1", "weight": 100},
+		{"id": "n2", "weight": 100},
+		{"id": "n3", "weight": 100},
+		{"id": "n4", "weight": 100}
+	],
+	"events": [
+		{"time": 20, "leave": "n2"},
+		{"time": 10, "join": {"id": "n5", "weight": 100}},
+		{"time": 30, "reweight": {"id": "n1", "weight": 50}},
+		{"time": 30, "reweight": {"id": "n3", "weight": 50}}
+	],
+	"keys": 20000,
+	"replicas": 2
+}`
+
+func TestScenario(t *testing.T) {
+	s, err := ReadScenario(strings.NewReader(testScenario))
+	require.NoError(t, err)
+
+	steps, err := s.Run()
+	require.NoError(t, err)
+	require.Len(t, steps, 4)
+
+	require.Equal(t, "initial", steps[0].Events)
+	require.Equal(t, 0.0, steps[0].PrimaryMoved)
+	require.Equal(t, 1.0, steps[0].ReplicaStability)
+	require.InDelta(t, 1, steps[0].MaxLoad, 0.05)
+
+	// a new node takes 1/5 of primaries
+	require.Equal(t, "join n5", steps[1].Events)
+	require.Equal(t, 5, steps[1].Nodes)
+	require.InDelta(t, 0.2, steps[1].PrimaryMoved, 0.02)
+	require.InDelta(t, 0.4, steps[1].ReplicasChanged, 0.03)
+
+	// only keys of the left node move
+	require.Equal(t, "leave n2", steps[2].Events)
+	require.InDelta(t, 0.2, steps[2].PrimaryMoved, 0.02)
+	require.InDelta(t, 0.8, steps[2].ReplicaStability, 0.02)
+
+	require.Equal(t, "reweight n1;reweight n3", steps[3].Events)
+	require.Equal(t, 4, steps[3].Nodes)
+	require.True(t, steps[3].PrimaryMoved > 0)
+}
+
+func TestScenarioInvalid(t *testing.T) {
+	for _, s := range []string{
+		`{"nodes": [], "keys": 0, "replicas": 1}`,
+		`{"nodes": [], "keys": 1, "replicas": 0}`,
+		`{"keys": 1, "replicas": 1, "events": [{"time": 1}]}`,
+		`{"keys": 1, "replicas": 1, "events": [{"time": 1, "leave": "a", "join": {"id": "b"}}]}`,
+		`{"keys": `,
+	} {
+		_, err := ReadScenario(strings.NewReader(s))
+		require.Error(t, err, s)
+	}
+
+	s, err := ReadScenario(strings.NewReader(`{"keys": 1, "replicas": 1, "events": [{"time": 1, "leave": "a"}]}`))
+	require.NoError(t, err)
+	_, err = s.Run()
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package hrw
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"sort"
+)
+
+// ScoreRelativeError is the bound of relative error of scores used by
+// weighted sorting. CompareScores can disagree with CompareScoresExact
+// only for nodes whose exact scores differ by less than this fraction:
+// distance is rounded to float64 once and multiplied by weight with
+// one more rounding, each introducing relative error of 2^-53 at most.
+const ScoreRelativeError = 1.0 / (1 << 51)
+
+// CompareScoresExact is a slow reference implementation of CompareScores
+// calculating weighted scores with math/big without any rounding. Nodes
+// with equal scores are ordered by distance like CompareScores does.
+// NaN weights are invalid (see ValidateWeights), such nodes are placed
+// after all others and ordered by distance between themselves.
+// It is intended for tests and tools verifying the fast path.
+func CompareScoresExact(nodeHashA, nodeHashB, objectHash uint64, weightA, weightB float64) int {
+	da, db := distance(nodeHashA, objectHash), distance(nodeHashB, objectHash)
+
+	nanA, nanB := math.IsNaN(weightA), math.IsNaN(weightB)
+	switch {
+	case nanA && !nanB:
+		return 1
+	case !nanA && nanB:
+		return -1
+	case !nanA:
+		// higher score is placed first
+		if c := exactScore(db, weightB).Cmp(exactScore(da, weightA)); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case da < db:
+		return -1
+	case da > db:
+		return 1
+	}
+	return 0
+}
+
+// exactScore returns (maxUint64 - dist) * weight. Product of a 64-bit
+// integer and a 53-bit mantissa fits into 117 bits, so 128-bit precision
+// makes it exact.
+func exactScore(dist uint64, weight float64) *big.Float {
+	a := new(big.Float).SetPrec(128).SetUint64(^uint64(0) - dist)
+	b := new(big.Float).SetPrec(128).SetFloat64(weight)
+	return a.Mul(a, b)
+}
+
+// SortByWeightU64 receive nodes, integer weights (e.g. capacities in bytes)
+// and hash, and sort it by distance * weight / max(weights). Scores are
+// compared exactly using 128-bit products, so large weights don't lose
+// precision on conversion to float64. Nodes with equal scores (including
+// all zero weights) are ordered by distance. Nodes with equal hashes have
+// equal distances, they are ordered by weight and then by index, so if all
+// hashes are equal nodes are just sorted by weight. An error is returned
+// if the number of weights doesn't match the number of nodes.
+func SortByWeightU64(nodes []uint64, weights []uint64, hash uint64) ([]uint64, error) {
+	if err := checkWeightsU64(len(nodes), weights); err != nil {
+		return nil, err
+	}
+	return toUint64s(sortByWeightU64(nodes, weights, hash)), nil
+}
+
+// MustSortByWeightU64 is SortByWeightU64 panicking on error, for callers
+// which validate weights themselves.
+func MustSortByWeightU64(nodes []uint64, weights []uint64, hash uint64) []uint64 {
+	res, err := SortByWeightU64(nodes, weights, hash)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// SortSliceByWeightU64Value received []T, integer weights and hash to sort
+// by value-distance * weights exactly like SortByWeightU64 does.
+// An error is returned if the number of weights doesn't match the slice
+// length, slice is left intact then.
+func SortSliceByWeightU64Value(slice interface{}, weights []uint64, hash uint64) error {
+	rule := prepareRule(slice)
+	if rule == nil {
+		return nil
+	}
+	if err := checkWeightsU64(len(rule), weights); err != nil {
+		return err
+	}
+	permute(sortByWeightU64(rule, weights, hash), reflect.Swapper(slice))
+	return nil
+}
+
+func checkWeightsU64(l int, weights []uint64) error {
+	if len(weights) != l {
+		return fmt.Errorf("%d weights for %d nodes", len(weights), l)
+	}
+	return nil
+}
+
+func sortByWeightU64(nodes []uint64, weights []uint64, hash uint64) []int {
+	dist := distances(len(nodes), false, nodes, hash)
+	s, ind := distSorter(dist)
+	s.less = func(i, j int) bool {
+		ii, jj := ind[i], ind[j]
+		if c := compareWeightedU64(dist[ii], dist[jj], weights[ii], weights[jj]); c != 0 {
+			return c < 0
+		}
+		// only equal node hashes get here
+		return ii < jj
+	}
+	sort.Sort(s)
+	return ind
+}
+
+// compareWeightedU64 is compareWeighted for integer weights. Common
+// normalization by the maximal weight doesn't change the order, so
+// (maxUint64 - distance) * weight products are compared directly.
+func compareWeightedU64(da, db uint64, wa, wb uint64) int {
+	ha, la := bits.Mul64(^uint64(0)-da, wa)
+	hb, lb := bits.Mul64(^uint64(0)-db, wb)
+	switch {
+	case ha > hb || ha == hb && la > lb:
+		return -1
+	case ha < hb || ha == hb && la < lb:
+		return 1
+	case da < db:
+		return -1
+	case da > db:
+		return 1
+	}
+	return 0
+}
+
+// WeightedScoresU64 returns scores of every node in the nodes order as
+// SortByWeightU64 calculates them: (maxUint64 - distance) * weight /
+// max(weights) rounded down. Nodes with higher scores are placed first,
+// scores of nodes are zero if all weights are zero. An error is returned
+// if the number of weights doesn't match the number of nodes.
+func WeightedScoresU64(nodes []uint64, weights []uint64, hash uint64) ([]uint64, error) {
+	if err := checkWeightsU64(len(nodes), weights); err != nil {
+		return nil, err
+	}
+
+	var max uint64
+	for _, w := range weights {
+		if w > max {
+			max = w
+		}
+	}
+
+	scores := make([]uint64, len(nodes))
+	if max == 0 {
+		return scores, nil
+	}
+	for i := range nodes {
+		// product is less than 2^64 * max, so the quotient fits into 64 bits
+		hi, lo := bits.Mul64(^uint64(0)-distance(nodes[i], hash), weights[i])
+		scores[i], _ = bits.Div64(hi, lo, max)
+	}
+	return scores, nil
+}
@@ -0,0 +1,258 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/nspcc-dev/hrw/normalizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareScoresExact(t *testing.T) {
+	var (
+		r    = rand.New(rand.NewSource(1))
+		hash = Hash(testKey)
+	)
+
+	check := func(t *testing.T, a, b uint64, wa, wb float64) bool {
+		exact := CompareScoresExact(a, b, hash, wa, wb)
+		require.Equal(t, -exact, CompareScoresExact(b, a, hash, wb, wa))
+		if CompareScores(a, b, hash, wa, wb) == exact {
+			return true
+		}
+
+		// the fast path can disagree only within the documented bound
+		sa, sb := exactScore(distance(a, hash), wa), exactScore(distance(b, hash), wb)
+		diff := new(big.Float).Sub(sa, sb)
+		bound := new(big.Float).Mul(sa, big.NewFloat(ScoreRelativeError))
+		require.True(t, diff.Abs(diff).Cmp(bound) <= 0, "difference %s exceeds bound %s", diff, bound)
+		return false
+	}
+
+	t.Run("random", func(t *testing.T) {
+		for i := 0; i < 100000; i++ {
+			var (
+				a, b   = r.Uint64(), r.Uint64()
+				wa, wb = r.Float64(), r.Float64()
+			)
+			if i%10 == 0 {
+				wb = wa
+			}
+			require.True(t, check(t, a, b, wa, wb))
+		}
+	})
+
+	t.Run("near ties", func(t *testing.T) {
+		// scores differing in the last bits can be ordered differently
+		// by the fast path, but only within the documented bound
+		var disagree int
+		for i := 0; i < 100000; i++ {
+			var (
+				da = r.Uint64() >> 1
+				db = da + uint64(r.Intn(1<<12))
+				wa = 0.5 + r.Float64()/2
+				wb = math.Nextafter(wa, 1)
+			)
+			if !check(t, nodeAt(da, hash), nodeAt(db, hash), wa, wb) {
+				disagree++
+			}
+		}
+		require.NotZero(t, disagree)
+	})
+
+	t.Run("equal weights", func(t *testing.T) {
+		require.Equal(t, -1, CompareScoresExact(nodeAt(1<<40, hash), nodeAt(1<<40+1, hash), hash, 0.5, 0.5))
+		require.Equal(t, 1, CompareScoresExact(nodeAt(1<<40, hash), nodeAt(1<<40+1, hash), hash, 0, 0.5))
+		require.Equal(t, 0, CompareScoresExact(7, 7, hash, 0.5, 0.5))
+	})
+
+	t.Run("NaN", func(t *testing.T) {
+		nan := math.NaN()
+		a, b := nodeAt(1<<40, hash), nodeAt(1<<40+1, hash)
+
+		require.Equal(t, 1, CompareScoresExact(a, b, hash, nan, 0))
+		require.Equal(t, -1, CompareScoresExact(b, a, hash, 0, nan))
+		require.Equal(t, -1, CompareScoresExact(a, b, hash, nan, nan))
+		require.Equal(t, 1, CompareScoresExact(b, a, hash, nan, nan))
+		require.Equal(t, 0, CompareScoresExact(7, 7, hash, nan, nan))
+	})
+}
+
+func TestSortByWeightU64(t *testing.T) {
+	var (
+		r     = rand.New(rand.NewSource(1))
+		nodes = make([]uint64, 20)
+		key   = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = r.Uint64()
+	}
+
+	t.Run("small weights", func(t *testing.T) {
+		var (
+			weights = make([]uint64, len(nodes))
+			fs      = make([]float64, len(nodes))
+		)
+		for i := range weights {
+			weights[i] = uint64(r.Intn(1000) + 1)
+		}
+		max := weights[0]
+		for _, w := range weights {
+			if w > max {
+				max = w
+			}
+		}
+		for i := range fs {
+			fs[i] = float64(weights[i]) / float64(max)
+		}
+
+		for k := uint64(0); k < 100; k++ {
+			binary.BigEndian.PutUint64(key, k)
+			hash := Hash(key)
+			require.Equal(t, SortByWeight(nodes, fs, hash)[0], MustSortByWeightU64(nodes, weights, hash)[0])
+		}
+	})
+
+	t.Run("same weights", func(t *testing.T) {
+		hash := Hash(testKey)
+		same := make([]uint64, len(nodes))
+		require.Equal(t, Sort(nodes, hash), MustSortByWeightU64(nodes, same, hash))
+		for i := range same {
+			same[i] = math.MaxUint64
+		}
+		require.Equal(t, Sort(nodes, hash), MustSortByWeightU64(nodes, same, hash))
+	})
+
+	t.Run("large weights", func(t *testing.T) {
+		// weights are equal after conversion to float64
+		var (
+			hash = Hash(testKey)
+			wa   = uint64(1<<62) + 1
+			wb   = uint64(1 << 62)
+			d    = distance(nodes[0], hash)
+		)
+		require.Equal(t, float64(wa), float64(wb))
+		require.Equal(t, 0, CompareScores(nodes[0], nodes[0], hash, float64(wa), float64(wb)))
+
+		require.Equal(t, -1, compareWeightedU64(d, d, wa, wb))
+		require.Equal(t, 1, compareWeightedU64(d, d, wb, wa))
+		require.Equal(t, 0, compareWeightedU64(d, d, wa, wa))
+		require.Equal(t, -1, compareWeightedU64(d, d+1, wa, wa))
+	})
+}
+
+func TestWeightedScoresU64(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4, 5}
+		weights = []uint64{1 << 40, 1 << 39, 3, 1 << 40, 0}
+	)
+
+	scores, err := WeightedScoresU64(nodes, weights, hash)
+	require.NoError(t, err)
+	order := MustSortByWeightU64(nodes, weights, hash)
+	for k := 1; k < len(order); k++ {
+		require.True(t, scores[order[k-1]] >= scores[order[k]])
+	}
+
+	// maximal weight keeps the distance, half of it halves the score
+	require.Equal(t, ^uint64(0)-distance(1, hash), scores[0])
+	require.Equal(t, (^uint64(0)-distance(2, hash))/2, scores[1])
+	require.Zero(t, scores[4])
+
+	scores, err = WeightedScoresU64(nodes[:2], []uint64{0, 0}, hash)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 0}, scores)
+}
+
+func TestSortSliceByWeightU64Value(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []string{"a", "b", "c", "d", "e", "f"}
+		weights = []uint64{1 << 50, 3 << 48, 1 << 40, 1 << 50, 5 << 47, 7}
+	)
+
+	actual := append([]string{}, nodes...)
+	require.NoError(t, SortSliceByWeightU64Value(actual, weights, hash))
+	for i, k := range MustSortByWeightU64(SliceHashes(nodes), weights, hash) {
+		require.Equal(t, nodes[k], actual[i])
+	}
+
+	actual = append(actual[:0], nodes...)
+	SortSliceByNormWeightU64Value(actual, weights, normalizer.AutoMaxU64(weights), hash)
+	require.Equal(t, SortedSliceByWeightValue(nodes, normalizer.ApplyU64(normalizer.AutoMaxU64(weights), weights), hash), actual)
+
+	unsupported := []unknown{1, 2}
+	require.NoError(t, SortSliceByWeightU64Value(unsupported, []uint64{1, 2}, hash))
+	require.Equal(t, []unknown{1, 2}, unsupported)
+}
+
+func TestWeightsNotMutated(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4}
+		values  = []string{"a", "b", "c", "d"}
+		weights = []uint64{100, 50, 0, 1 << 60}
+		fs      = []float64{10, 5, 0, 7}
+	)
+	expect := append([]uint64{}, weights...)
+	expectF := append([]float64{}, fs...)
+
+	_, _ = SortByWeightU64(nodes, weights, hash)
+	_, _ = WeightedScoresU64(nodes, weights, hash)
+	_ = SortSliceByWeightU64Value(values, weights, hash)
+	SortSliceByNormWeightU64Value(values, weights, normalizer.AutoMaxU64(weights), hash)
+	require.Equal(t, expect, weights)
+
+	SortSliceByAutoWeightValue(values, fs, hash)
+	_, _ = SortByWeightBatch(nodes, fs, normalizer.AutoMax(fs), []uint64{hash})
+	require.Equal(t, expectF, fs)
+}
+
+func TestWeightsU64Mismatch(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3}
+		weights = []uint64{1, 2}
+		values  = []string{"a", "b", "c"}
+	)
+
+	_, err := SortByWeightU64(nodes, weights, hash)
+	require.Error(t, err)
+	_, err = WeightedScoresU64(nodes, weights, hash)
+	require.Error(t, err)
+	require.Error(t, SortSliceByWeightU64Value(values, weights, hash))
+	require.Equal(t, []string{"a", "b", "c"}, values)
+	require.Panics(t, func() { MustSortByWeightU64(nodes, weights, hash) })
+
+	_, err = SortByWeightU64(nil, nil, hash)
+	require.NoError(t, err)
+}
+
+func TestSortByWeightU64EqualHashes(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = []uint64{7, 7, 7, 7, 7}
+	)
+
+	res, err := SortByWeightU64(nodes, []uint64{1, 5, 3, 5, 0}, hash)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 3, 2, 0, 4}, res)
+
+	res, err = SortByWeightU64(nodes, []uint64{2, 2, 2, 2, 2}, hash)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1, 2, 3, 4}, res)
+
+	res, err = SortByWeightU64(nodes, make([]uint64, len(nodes)), hash)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1, 2, 3, 4}, res)
+
+	// only nodes with equal hashes are affected
+	res, err = SortByWeightU64([]uint64{7, 8, 7}, []uint64{1, 1, 1}, hash)
+	require.NoError(t, err)
+	require.Equal(t, Sort([]uint64{7, 8}, hash)[0] == 0, res[0] == 0)
+	require.True(t, indexOf(res, 0) < indexOf(res, 2))
+}
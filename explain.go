@@ -0,0 +1,59 @@
+package hrw
+
+// Explanation contains details about the position of the node
+// for the object.
+type Explanation struct {
+	// Index is the index of the node in the original slice.
+	Index int
+	// Hash is the node hash.
+	Hash uint64
+	// Distance is the distance between the node hash and the object hash.
+	Distance uint64
+	// Weight is the node weight, 1 if sorting is not weighted.
+	Weight float64
+	// Score is the distance scaled by weight, nodes with higher
+	// score are placed first.
+	Score float64
+}
+
+// Explain returns details about every node in the order SortByWeight
+// places them. weights can be nil, nodes are sorted by distance then.
+func Explain(nodes []uint64, weights []float64, hash uint64) []Explanation {
+	if weights == nil {
+		weights = make([]float64, len(nodes))
+		for i := range weights {
+			weights[i] = NormalizedMaxWeight
+		}
+	}
+
+	order := sortByWeight(len(nodes), false, nodes, weights, hash, nil)
+	result := make([]Explanation, len(order))
+	for k, i := range order {
+		d := distance(nodes[i], hash)
+		result[k] = Explanation{
+			Index:    i,
+			Hash:     nodes[i],
+			Distance: d,
+			Weight:   weights[i],
+			Score:    weightedScore(d, weights[i]),
+		}
+	}
+	return result
+}
+
+// Distances returns distances between every node and the object in the
+// nodes order, Sort places nodes with shorter distances first.
+func Distances(nodes []uint64, hash uint64) []uint64 {
+	return distances(len(nodes), false, nodes, hash)
+}
+
+// WeightedScores returns scores of every node in the nodes order as
+// SortByWeight calculates them, nodes with higher scores are placed first.
+// Note that if all weights are equal SortByWeight ignores them.
+func WeightedScores(nodes []uint64, weights []float64, hash uint64) []float64 {
+	scores := make([]float64, len(nodes))
+	for i := range nodes {
+		scores[i] = weightedScore(distance(nodes[i], hash), weights[i])
+	}
+	return scores
+}
@@ -0,0 +1,69 @@
+package hrw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4, 5, 6}
+		weights = []float64{1, 0.3, 1, 0.2, 0.7, 0.2}
+	)
+
+	t.Run("unweighted", func(t *testing.T) {
+		ex := Explain(nodes, nil, hash)
+		order := Sort(nodes, hash)
+		require.Len(t, ex, len(nodes))
+		for k := range ex {
+			i := order[k]
+			require.Equal(t, int(i), ex[k].Index)
+			require.Equal(t, nodes[i], ex[k].Hash)
+			require.Equal(t, distance(nodes[i], hash), ex[k].Distance)
+			require.Equal(t, 1.0, ex[k].Weight)
+			if k > 0 {
+				require.True(t, ex[k-1].Distance < ex[k].Distance)
+			}
+		}
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		ex := Explain(nodes, weights, hash)
+		order := SortByWeight(nodes, weights, hash)
+		for k := range ex {
+			require.Equal(t, int(order[k]), ex[k].Index)
+			require.Equal(t, weights[order[k]], ex[k].Weight)
+			if k > 0 {
+				require.True(t, ex[k-1].Score >= ex[k].Score)
+			}
+		}
+	})
+}
+
+func TestDistances(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4, 5}
+		weights = []float64{1, 0.5, 0.2, 0.8, 0.3}
+	)
+
+	dist := Distances(nodes, hash)
+	order := Sort(nodes, hash)
+	for k := 1; k < len(order); k++ {
+		require.True(t, dist[order[k-1]] < dist[order[k]])
+	}
+
+	scores := WeightedScores(nodes, weights, hash)
+	order = SortByWeight(nodes, weights, hash)
+	for k := 1; k < len(order); k++ {
+		require.True(t, scores[order[k-1]] >= scores[order[k]])
+	}
+	for _, ex := range Explain(nodes, weights, hash) {
+		require.Equal(t, ex.Distance, dist[ex.Index])
+		require.Equal(t, ex.Score, scores[ex.Index])
+	}
+
+	require.Empty(t, Distances(nil, hash))
+}
@@ -0,0 +1,27 @@
+// Package fixed64 implements 0.64 fixed-point arithmetic used by the
+// library to compute weights from uint64 counters without losing precision,
+// so float-free weight computations downstream can match it exactly.
+// A value r represents r / 2^64, i.e. numbers in [0, 1).
+package fixed64
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Ratio returns a / b as 0.64 fixed-point number, i.e. floor(a * 2^64 / b)
+// computed with 128-bit division. The result fits into 64 bits only if
+// a < b, otherwise (including b == 0) Ratio saturates to math.MaxUint64.
+func Ratio(a, b uint64) uint64 {
+	if a >= b {
+		return math.MaxUint64
+	}
+	q, _ := bits.Div64(a, 0, b)
+	return q
+}
+
+// Float64 converts 0.64 fixed-point number into float64. Rounding is
+// performed once, so the result is the float64 nearest to r / 2^64.
+func Float64(r uint64) float64 {
+	return math.Ldexp(float64(r), -64)
+}
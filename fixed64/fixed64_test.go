@@ -0,0 +1,33 @@
+package fixed64
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatio(t *testing.T) {
+	require.Equal(t, uint64(1<<63), Ratio(1, 2))
+	require.Equal(t, uint64(1<<62), Ratio(25, 100))
+	require.Equal(t, uint64(0), Ratio(0, 7))
+	require.Equal(t, uint64(math.MaxUint64/3), Ratio(1, 3))
+
+	t.Run("huge values", func(t *testing.T) {
+		require.Equal(t, uint64(1<<63-1), Ratio(math.MaxUint64/2, math.MaxUint64))
+		require.Equal(t, uint64(math.MaxUint64-1), Ratio(math.MaxUint64-1, math.MaxUint64))
+	})
+
+	t.Run("saturation", func(t *testing.T) {
+		require.Equal(t, uint64(math.MaxUint64), Ratio(2, 2))
+		require.Equal(t, uint64(math.MaxUint64), Ratio(3, 2))
+		require.Equal(t, uint64(math.MaxUint64), Ratio(1, 0))
+		require.Equal(t, uint64(math.MaxUint64), Ratio(0, 0))
+	})
+}
+
+func TestFloat64(t *testing.T) {
+	require.Equal(t, 0.5, Float64(Ratio(1, 2)))
+	require.Equal(t, 0.0, Float64(0))
+	require.Equal(t, 1.0, Float64(math.MaxUint64))
+}
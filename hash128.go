@@ -0,0 +1,76 @@
+package hrw
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/spaolacci/murmur3"
+)
+
+type (
+	// Uint128 is 128-bit hash of a node or an object. With billions of
+	// objects 64-bit hashes start colliding, 128-bit ones practically don't.
+	Uint128 struct{ Hi, Lo uint64 }
+
+	// Hasher128 interface used by SortSliceByValue128 to hash elements.
+	Hasher128 interface{ Hash128() Uint128 }
+)
+
+// Hash128 uses murmur3 128-bit hash to return Uint128.
+func Hash128(key []byte) Uint128 {
+	hi, lo := murmur3.Sum128(key)
+	return Uint128{Hi: hi, Lo: lo}
+}
+
+// Less reports whether u is less than v.
+func (u Uint128) Less(v Uint128) bool {
+	return u.Hi < v.Hi || u.Hi == v.Hi && u.Lo < v.Lo
+}
+
+// Distance128 returns distance between 128-bit node and object hashes,
+// both halves of the distance depend on all bits of the hashes.
+func Distance128(node, object Uint128) Uint128 {
+	lo := distance(node.Lo, object.Lo)
+	hi := distance(node.Hi^object.Hi, lo)
+	return Uint128{Hi: hi, Lo: distance(lo, hi)}
+}
+
+// Sort128 receive 128-bit node hashes and object hash, and sort it by
+// distance, see Distance128.
+func Sort128(nodes []Uint128, hash Uint128) []uint64 {
+	var (
+		dist = make([]Uint128, len(nodes))
+		ind  = make([]uint64, len(nodes))
+	)
+	for i := range nodes {
+		ind[i] = uint64(i)
+		dist[i] = Distance128(nodes[i], hash)
+	}
+	sort.Slice(ind, func(i, j int) bool {
+		return dist[ind[i]].Less(dist[ind[j]])
+	})
+	return ind
+}
+
+// SortSliceByValue128 received []T with elements implementing Hasher128
+// and 128-bit hash to sort by value-distance. Other slices are left intact.
+func SortSliceByValue128(slice interface{}, hash Uint128) {
+	val := reflect.ValueOf(slice)
+	if val.Kind() != reflect.Slice || val.Len() == 0 {
+		return
+	}
+	if _, ok := val.Index(0).Interface().(Hasher128); !ok {
+		return
+	}
+
+	nodes := make([]Uint128, val.Len())
+	for i := range nodes {
+		nodes[i] = val.Index(i).Interface().(Hasher128).Hash128()
+	}
+	ind := Sort128(nodes, hash)
+	perm := make([]int, len(ind))
+	for i := range ind {
+		perm[i] = int(ind[i])
+	}
+	permute(perm, reflect.Swapper(slice))
+}
@@ -0,0 +1,86 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/spaolacci/murmur3"
+	"github.com/stretchr/testify/require"
+)
+
+type hashString128 string
+
+func (h hashString128) Hash128() Uint128 { return Hash128([]byte(h)) }
+
+func TestHash128(t *testing.T) {
+	hi, lo := murmur3.Sum128(testKey)
+	require.Equal(t, Uint128{Hi: hi, Lo: lo}, Hash128(testKey))
+
+	require.True(t, Uint128{1, 0}.Less(Uint128{1, 1}))
+	require.True(t, Uint128{0, 5}.Less(Uint128{1, 0}))
+	require.False(t, Uint128{1, 0}.Less(Uint128{1, 0}))
+}
+
+func TestDistance128(t *testing.T) {
+	var (
+		node = Hash128([]byte("node"))
+		obj  = Hash128(testKey)
+		d    = Distance128(node, obj)
+	)
+
+	// objects differing in either half get unrelated distances
+	lo, hi := obj, obj
+	lo.Lo ^= 1
+	hi.Hi ^= 1
+	require.NotEqual(t, d.Hi, Distance128(node, lo).Hi)
+	require.NotEqual(t, d.Hi, Distance128(node, hi).Hi)
+	require.NotEqual(t, d.Lo, Distance128(node, hi).Lo)
+}
+
+func TestSort128(t *testing.T) {
+	var (
+		nodes  = make([]Uint128, 10)
+		key    = make([]byte, 8)
+		counts = make([]int, len(nodes))
+		total  = 10000
+	)
+	for i := range nodes {
+		nodes[i] = Hash128([]byte(strconv.Itoa(i)))
+	}
+
+	for i := 0; i < total; i++ {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		res := Sort128(nodes, Hash128(key))
+		require.Len(t, res, len(nodes))
+		for j := 1; j < len(res); j++ {
+			require.True(t, Distance128(nodes[res[j-1]], Hash128(key)).Less(Distance128(nodes[res[j]], Hash128(key))))
+		}
+		counts[res[0]]++
+	}
+	for _, c := range counts {
+		require.InDelta(t, total/len(nodes), c, float64(total)/20)
+	}
+}
+
+func TestSortSliceByValue128(t *testing.T) {
+	var (
+		hash   = Hash128(testKey)
+		actual = []hashString128{"a", "b", "c", "d", "e", "f"}
+		nodes  = make([]Uint128, len(actual))
+	)
+	for i := range actual {
+		nodes[i] = actual[i].Hash128()
+	}
+	ind := Sort128(nodes, hash)
+
+	SortSliceByValue128(actual, hash)
+	for i := range ind {
+		require.Equal(t, hashString128([]string{"a", "b", "c", "d", "e", "f"}[ind[i]]), actual[i])
+	}
+
+	unknown := []string{"a", "b"}
+	SortSliceByValue128(unknown, hash)
+	require.Equal(t, []string{"a", "b"}, unknown)
+	require.NotPanics(t, func() { SortSliceByValue128(10, hash) })
+}
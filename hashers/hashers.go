@@ -0,0 +1,46 @@
+// Package hashers provides hrw.Hasher implementations over common hash
+// algorithms, so callers can choose speed or cryptographic strength without
+// writing their own adapters. Every hasher is a conversion of the key bytes,
+// e.g. hashers.XXH64(key), and gives the same hash on all platforms.
+package hashers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+)
+
+type (
+	// XXH64 hashes bytes with xxHash64 (zero seed).
+	XXH64 []byte
+
+	// FNV1a hashes bytes with 64-bit FNV-1a.
+	FNV1a []byte
+
+	// SHA256 hashes bytes with SHA-256 taking the first 8 bytes of the
+	// digest as big-endian number.
+	SHA256 []byte
+
+	// SipHash hashes bytes with SipHash-2-4 and zero key. It is mostly
+	// useful with a secret key, see SipHash24.
+	SipHash []byte
+)
+
+// Hash implements hrw.Hasher.
+func (b XXH64) Hash() uint64 { return Sum64XXH(b, 0) }
+
+// Hash implements hrw.Hasher.
+func (b FNV1a) Hash() uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	return h.Sum64()
+}
+
+// Hash implements hrw.Hasher.
+func (b SHA256) Hash() uint64 {
+	sum := sha256.Sum256(b)
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+// Hash implements hrw.Hasher.
+func (b SipHash) Hash() uint64 { return SipHash24(0, 0, b) }
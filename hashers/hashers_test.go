@@ -0,0 +1,89 @@
+package hashers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"testing"
+
+	"github.com/nspcc-dev/hrw"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSum64XXH(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		sum uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"as", 0x1c330fb2d66be179},
+		{"asd", 0x631c37ce72a97393},
+		{"asdf", 0x415872f599cea71e},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+		{"Call me Ishmael. Some years ago--never mind how long precisely-", 0x02a2e85470d6fd96},
+	} {
+		require.Equal(t, tc.sum, Sum64XXH([]byte(tc.in), 0), tc.in)
+		require.Equal(t, tc.sum, XXH64(tc.in).Hash(), tc.in)
+	}
+}
+
+func TestSipHash24(t *testing.T) {
+	// reference vectors from the paper: key 00..0f, message 00..(n-1)
+	var (
+		k0  = uint64(0x0706050403020100)
+		k1  = uint64(0x0f0e0d0c0b0a0908)
+		msg = make([]byte, 15)
+	)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	require.Equal(t, uint64(0x726fdb47dd0e0e31), SipHash24(k0, k1, nil))
+	require.Equal(t, uint64(0xa129ca6149be45e5), SipHash24(k0, k1, msg))
+	require.Equal(t, SipHash24(0, 0, msg), SipHash(msg).Hash())
+}
+
+func TestHashers(t *testing.T) {
+	key := []byte("object")
+
+	f := fnv.New64a()
+	_, _ = f.Write(key)
+	require.Equal(t, f.Sum64(), FNV1a(key).Hash())
+
+	sum := sha256.Sum256(key)
+	require.Equal(t, binary.BigEndian.Uint64(sum[:]), SHA256(key).Hash())
+
+	// pinned values, changing them changes placement of every object
+	require.Equal(t, uint64(0x5bffbffeae195fc9), XXH64(key).Hash())
+	require.Equal(t, uint64(0x8dfeaf9950df0ffa), FNV1a(key).Hash())
+	require.Equal(t, uint64(0x2958d416d08aa5a4), SHA256(key).Hash())
+	require.Equal(t, uint64(0x8f1cd770d4b0851b), SipHash(key).Hash())
+
+	nodes := []SHA256{SHA256("a"), SHA256("b"), SHA256("c"), SHA256("d")}
+	hrw.SortSliceByValue(nodes, XXH64(key).Hash())
+	require.Equal(t, []SHA256{SHA256("b"), SHA256("d"), SHA256("c"), SHA256("a")}, nodes)
+}
+
+func TestHashersSeeds(t *testing.T) {
+	key := []byte("object")
+
+	require.Equal(t, uint64(0xe29978af821d43ca), Sum64XXH(key, 1))
+	require.NotEqual(t, Sum64XXH(key, 0), Sum64XXH(key, 1))
+
+	sums := map[uint64]struct{}{
+		SipHash24(0, 0, key): {},
+		SipHash24(1, 0, key): {},
+		SipHash24(0, 1, key): {},
+	}
+	require.Len(t, sums, 3)
+
+	for _, h := range []func(b []byte) uint64{
+		func(b []byte) uint64 { return XXH64(b).Hash() },
+		func(b []byte) uint64 { return FNV1a(b).Hash() },
+		func(b []byte) uint64 { return SHA256(b).Hash() },
+		func(b []byte) uint64 { return SipHash(b).Hash() },
+	} {
+		require.NotEqual(t, h([]byte("object")), h([]byte("objecT")))
+		require.NotEqual(t, h(nil), h([]byte{0}))
+	}
+}
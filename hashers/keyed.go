@@ -0,0 +1,32 @@
+package hashers
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// KeyedSize is the size of the secret used by Keyed.
+const KeyedSize = 16
+
+// Keyed hashes object keys with SipHash-2-4 under a secret, so clients not
+// knowing it can't craft keys landing on the same node, while the mapping
+// stays deterministic for all cluster members sharing the secret.
+type Keyed struct {
+	k0, k1 uint64
+}
+
+// NewKeyed returns Keyed hasher with the given KeyedSize-byte secret.
+func NewKeyed(secret []byte) (Keyed, error) {
+	if len(secret) != KeyedSize {
+		return Keyed{}, errors.New("secret must be 16 bytes long")
+	}
+	return Keyed{
+		k0: binary.LittleEndian.Uint64(secret),
+		k1: binary.LittleEndian.Uint64(secret[8:]),
+	}, nil
+}
+
+// Sum64 returns keyed hash of b to be used as object hash.
+func (k Keyed) Sum64(b []byte) uint64 {
+	return SipHash24(k.k0, k.k1, b)
+}
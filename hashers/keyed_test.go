@@ -0,0 +1,29 @@
+package hashers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyed(t *testing.T) {
+	secret := make([]byte, KeyedSize)
+	for i := range secret {
+		secret[i] = byte(i)
+	}
+
+	_, err := NewKeyed(secret[:8])
+	require.Error(t, err)
+	_, err = NewKeyed(append(secret, 0))
+	require.Error(t, err)
+
+	k, err := NewKeyed(secret)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x726fdb47dd0e0e31), k.Sum64(nil))
+	require.Equal(t, k.Sum64([]byte("object")), k.Sum64([]byte("object")))
+
+	secret[0] ^= 1
+	other, err := NewKeyed(secret)
+	require.NoError(t, err)
+	require.NotEqual(t, k.Sum64([]byte("object")), other.Sum64([]byte("object")))
+}
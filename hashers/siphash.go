@@ -0,0 +1,60 @@
+package hashers
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// SipHash24 returns SipHash-2-4 of b with 128-bit key given as two
+// little-endian halves k0 and k1.
+// https://www.aumasson.jp/siphash/siphash.pdf
+func SipHash24(k0, k1 uint64, b []byte) uint64 {
+	var (
+		n  = len(b)
+		v0 = k0 ^ 0x736f6d6570736575
+		v1 = k1 ^ 0x646f72616e646f6d
+		v2 = k0 ^ 0x6c7967656e657261
+		v3 = k1 ^ 0x7465646279746573
+	)
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	for ; len(b) >= 8; b = b[8:] {
+		m := binary.LittleEndian.Uint64(b)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	m := uint64(n) << 56
+	for i, c := range b {
+		m |= uint64(c) << (8 * uint(i))
+	}
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+	return v0 ^ v1 ^ v2 ^ v3
+}
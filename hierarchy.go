@@ -0,0 +1,140 @@
+package hrw
+
+import "fmt"
+
+// Level is a level of the hierarchy used by SortHierarchy, e.g. regions,
+// datacenters or zones.
+type Level struct {
+	// Group returns ID of the group the i-th node belongs to at this level.
+	// IDs only need to be unique inside the group of the previous level.
+	Group func(i int) string
+	// Weight returns weight of the group in [0.0, 1.0] range. Groups are
+	// sorted by distance only if it is nil.
+	Weight func(group string) float64
+	// Count is the number of groups selected at this level inside every
+	// group selected at the previous one. Non-positive Count selects all
+	// of them.
+	Count int
+}
+
+// SortHierarchy receive nodes, weights, hierarchy levels starting from the
+// top one and hash, and select nodes level by level. Groups of the first
+// level are sorted by distance between the hash of the group ID and the
+// object hash (scaled by group weights if any) and Count best ones are
+// taken, then groups of the next level are sorted the same way inside every
+// selected group and so on. Finally perGroup nodes are taken from every
+// group of the last level sorted by distance * weights, or by distance if
+// weights is nil. Non-positive perGroup takes all nodes of the group.
+//
+// Result contains node indices grouped by selected groups in HRW order, so
+// the first index is the primary node. E.g. levels of regions with Count 1
+// and zones with Count 3 with perGroup 1 yield three nodes of the same
+// region, one per zone. Without levels it is SortByWeight (or Sort)
+// limited to perGroup nodes. An error is returned if node or group weights
+// are not normalized or the number of weights doesn't match the number of
+// nodes.
+func SortHierarchy(nodes []uint64, weights []float64, levels []Level, perGroup int, hash uint64) ([]uint64, error) {
+	if weights != nil {
+		if len(weights) != len(nodes) {
+			return nil, fmt.Errorf("%d weights for %d nodes", len(weights), len(nodes))
+		}
+		if err := ValidateWeights(weights); err != nil {
+			return nil, err
+		}
+	}
+
+	cand := make([]int, len(nodes))
+	for i := range cand {
+		cand[i] = i
+	}
+	h := hierarchy{nodes: nodes, weights: weights, levels: levels, perGroup: perGroup, hash: hash}
+	return h.sort(0, cand, make([]uint64, 0, len(nodes)))
+}
+
+type hierarchy struct {
+	nodes    []uint64
+	weights  []float64
+	levels   []Level
+	perGroup int
+	hash     uint64
+}
+
+// sort appends nodes selected among cand starting from the given level to res.
+func (h *hierarchy) sort(level int, cand []int, res []uint64) ([]uint64, error) {
+	if level == len(h.levels) {
+		return h.sortNodes(cand, res), nil
+	}
+
+	var (
+		lv      = h.levels[level]
+		ids     []string
+		members = make(map[string][]int)
+	)
+	for _, i := range cand {
+		id := lv.Group(i)
+		if _, ok := members[id]; !ok {
+			ids = append(ids, id)
+		}
+		members[id] = append(members[id], i)
+	}
+
+	var (
+		ord  []int
+		dist = make([]uint64, len(ids))
+		tie  = func(i, j int) bool { return ids[i] < ids[j] }
+	)
+	for k := range ids {
+		dist[k] = distance(HashString(ids[k]), h.hash)
+	}
+	if lv.Weight != nil {
+		ws := make([]float64, len(ids))
+		for k := range ids {
+			ws[k] = lv.Weight(ids[k])
+		}
+		if err := ValidateWeights(ws); err != nil {
+			return nil, fmt.Errorf("level %d: %w", level, err)
+		}
+		ord = weightOrder(dist, ws, tie)
+	} else {
+		ord = distanceOrder(dist, tie)
+	}
+
+	if lv.Count > 0 && lv.Count < len(ord) {
+		ord = ord[:lv.Count]
+	}
+
+	var err error
+	for _, k := range ord {
+		if res, err = h.sort(level+1, members[ids[k]], res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// sortNodes appends perGroup best nodes among cand to res.
+func (h *hierarchy) sortNodes(cand []int, res []uint64) []uint64 {
+	dist := make([]uint64, len(cand))
+	for k, i := range cand {
+		dist[k] = distance(h.nodes[i], h.hash)
+	}
+
+	var ord []int
+	if h.weights != nil {
+		ws := make([]float64, len(cand))
+		for k, i := range cand {
+			ws[k] = h.weights[i]
+		}
+		ord = weightOrder(dist, ws, nil)
+	} else {
+		ord = distanceOrder(dist, nil)
+	}
+
+	if h.perGroup > 0 && h.perGroup < len(ord) {
+		ord = ord[:h.perGroup]
+	}
+	for _, k := range ord {
+		res = append(res, uint64(cand[k]))
+	}
+	return res
+}
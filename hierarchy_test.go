@@ -0,0 +1,129 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortHierarchy(t *testing.T) {
+	// 3 regions with 3 zones with 4 nodes each
+	var (
+		nodes   = make([]uint64, 36)
+		regions = make([]string, len(nodes))
+		zones   = make([]string, len(nodes))
+		key     = make([]byte, 8)
+		levels  = []Level{
+			{Group: func(i int) string { return regions[i] }, Count: 1},
+			{Group: func(i int) string { return zones[i] }, Count: 3},
+		}
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+		regions[i] = "region-" + strconv.Itoa(i/12)
+		zones[i] = "zone-" + strconv.Itoa(i/4%3)
+	}
+
+	primaries := make(map[string]int)
+	for k := uint64(0); k < 1000; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		res, err := SortHierarchy(nodes, nil, levels, 1, hash)
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+
+		seen := make(map[string]bool)
+		for _, i := range res {
+			require.Equal(t, regions[res[0]], regions[i])
+			require.False(t, seen[zones[i]])
+			seen[zones[i]] = true
+		}
+		primaries[regions[res[0]]]++
+
+		// primary is the best node of its zone
+		var zone []uint64
+		for i := range nodes {
+			if regions[i] == regions[res[0]] && zones[i] == zones[res[0]] {
+				zone = append(zone, nodes[i])
+			}
+		}
+		require.Equal(t, nodes[res[0]], zone[Sort(zone, hash)[0]])
+	}
+	require.Len(t, primaries, 3)
+	for _, c := range primaries {
+		require.InDelta(t, 333, c, 80)
+	}
+
+	t.Run("all groups", func(t *testing.T) {
+		res, err := SortHierarchy(nodes, nil, []Level{{Group: levels[1].Group}}, 0, Hash(testKey))
+		require.NoError(t, err)
+		require.Len(t, res, len(nodes))
+		for i := 1; i < len(res); i++ {
+			// nodes of every zone are kept together
+			if zones[res[i]] != zones[res[i-1]] {
+				for _, j := range res[i:] {
+					require.NotEqual(t, zones[res[i-1]], zones[j])
+				}
+			}
+		}
+	})
+
+	t.Run("no levels", func(t *testing.T) {
+		weights := make([]float64, len(nodes))
+		for i := range weights {
+			weights[i] = float64(i+1) / float64(len(nodes))
+		}
+		hash := Hash(testKey)
+
+		res, err := SortHierarchy(nodes, nil, nil, 5, hash)
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, hash)[:5], res)
+
+		res, err = SortHierarchy(nodes, weights, nil, 0, hash)
+		require.NoError(t, err)
+		require.Equal(t, SortByWeight(nodes, weights, hash), res)
+	})
+
+	t.Run("group weights", func(t *testing.T) {
+		var (
+			first = make(map[string]int)
+			lv    = []Level{{
+				Group: func(i int) string { return regions[i] },
+				Weight: func(g string) float64 {
+					if g == "region-0" {
+						return 0
+					}
+					return 1
+				},
+			}}
+		)
+		for k := uint64(0); k < 100; k++ {
+			binary.BigEndian.PutUint64(key, k)
+			res, err := SortHierarchy(nodes, nil, lv, 1, Hash(key))
+			require.NoError(t, err)
+			require.Len(t, res, 3)
+			require.Equal(t, "region-0", regions[res[2]])
+			first[regions[res[0]]]++
+		}
+		require.Zero(t, first["region-0"])
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		_, err := SortHierarchy(nodes, []float64{1}, levels, 1, 0)
+		require.Error(t, err)
+
+		bad := []Level{{Group: levels[0].Group, Weight: func(string) float64 { return 2 }}}
+		_, err = SortHierarchy(nodes, nil, bad, 1, 0)
+		require.True(t, errors.Is(err, ErrWeightOutOfRange))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		res, err := SortHierarchy(nil, nil, levels, 1, 0)
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+}
@@ -5,10 +5,15 @@ package hrw
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash"
+	"io"
 	"math"
 	"reflect"
 	"sort"
+	"unsafe"
 
+	"github.com/nspcc-dev/hrw/normalizer"
 	"github.com/spaolacci/murmur3"
 )
 
@@ -16,6 +21,15 @@ type (
 	// Hasher interface used by SortSliceByValue
 	Hasher interface{ Hash() uint64 }
 
+	// DistanceFunc returns distance between node and object hashes,
+	// nodes with shorter distances are placed first. See Distance.
+	DistanceFunc func(node, object uint64) uint64
+
+	// Hash64 adapts in-progress hash.Hash64 state (e.g. fnv or crc64) to
+	// Hasher using its Sum64, so hashes callers already compute for other
+	// purposes can serve as object keys and node IDs without hashing twice.
+	Hash64 struct{ hash.Hash64 }
+
 	sorter struct {
 		l    int
 		less func(i, j int) bool
@@ -45,11 +59,63 @@ func distance(x uint64, y uint64) uint64 {
 	return acc
 }
 
+// Hash implements Hasher interface.
+func (h Hash64) Hash() uint64 { return h.Sum64() }
+
+// Distance is the default DistanceFunc used by all sorting functions:
+// XOR of hashes mixed with mmh3 64-bit finalizer.
+func Distance(node, object uint64) uint64 { return distance(node, object) }
+
+// DistanceV2 is an opt-in DistanceFunc mixing XOR of hashes with moremur
+// finalizer, which has better avalanche than the mmh3 one. Placements
+// computed with it differ from the default ones, so switching must be
+// coordinated across all users of the same placement, e.g. via
+// Selector.Distance.
+func DistanceV2(node, object uint64) uint64 {
+	acc := node ^ object
+	// http://mostlymangling.blogspot.com/2019/12/stronger-better-morer-moremur-better.html
+	acc ^= acc >> 27
+	acc *= 0x3c79ac492ba7b653
+	acc ^= acc >> 33
+	acc *= 0x1c69b3f74ac4ae35
+	acc ^= acc >> 27
+	return acc
+}
+
 // Hash uses murmur3 hash to return uint64
 func Hash(key []byte) uint64 {
 	return murmur3.Sum64(key)
 }
 
+// NewHash64 returns Hash64 over murmur3 state, so composite keys can be
+// written in parts without buffering them. Its Hash is equal to Hash of
+// all bytes written.
+func NewHash64() Hash64 {
+	return Hash64{murmur3.New64()}
+}
+
+// HashReader returns Hash of all data read from r until io.EOF.
+func HashReader(r io.Reader) (uint64, error) {
+	h := NewHash64()
+	if _, err := io.Copy(h, r); err != nil {
+		return 0, err
+	}
+	return h.Hash(), nil
+}
+
+// HashString is Hash of the string bytes which doesn't copy them,
+// so hashing string keys doesn't allocate.
+func HashString(key string) uint64 {
+	var (
+		b  []byte
+		sh = (*reflect.StringHeader)(unsafe.Pointer(&key))
+		bh = (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	)
+	bh.Data, bh.Len, bh.Cap = sh.Data, sh.Len, sh.Len
+	// murmur3 only reads the key
+	return murmur3.Sum64(b)
+}
+
 // Sort receive nodes and hash, and sort it by distance
 func Sort(nodes []uint64, hash uint64) []uint64 {
 	l := len(nodes)
@@ -66,20 +132,96 @@ func Sort(nodes []uint64, hash uint64) []uint64 {
 	return sorted
 }
 
-// SortByWeight receive nodes, weights and hash, and sort it by distance * weight
+// SeedHash mixes deployment-specific seed into the object hash, so that
+// services using the same node IDs and keys but different seeds get
+// independent orderings. The result can be passed to any sorting function.
+func SeedHash(hash, seed uint64) uint64 {
+	return distance(hash, seed)
+}
+
+// SortSeeded receive nodes, hash and seed, and sort it by distance to the
+// seeded hash, see SeedHash.
+func SortSeeded(nodes []uint64, hash, seed uint64) []uint64 {
+	return Sort(nodes, SeedHash(hash, seed))
+}
+
+// SortByWeightSeeded receive nodes, weights, hash and seed, and sort it by
+// distance to the seeded hash * weight, see SeedHash.
+func SortByWeightSeeded(nodes []uint64, weights []float64, hash, seed uint64) []uint64 {
+	return SortByWeight(nodes, weights, SeedHash(hash, seed))
+}
+
+// SortByWeight receive nodes, weights and hash, and sort it by distance * weight.
+// If all weights are equal (in particular, all are zero, which is what metric
+// outages usually produce), nodes are sorted by distance like Sort does.
+// Selector can report such weights as ErrZeroWeights instead.
 func SortByWeight(nodes []uint64, weights []float64, hash uint64) []uint64 {
-	result := make([]uint64, len(nodes))
-	copy(nodes, result)
-	sortByWeight(len(nodes), false, nodes, weights, hash, reflect.Swapper(result))
-	return result
+	return toUint64s(sortByWeight(len(nodes), false, nodes, weights, hash, nil))
+}
+
+// SortBytes receive raw node IDs and object key, and sort them by distance.
+// IDs and the key are hashed with Hash, so the order is the same as for
+// SortSliceByValue over the same IDs.
+func SortBytes(ids [][]byte, key []byte) []uint64 {
+	return Sort(hashBytes(ids), Hash(key))
+}
+
+// SortBytesByWeight receive raw node IDs, weights and object key,
+// and sort them by distance * weight, see SortBytes.
+func SortBytesByWeight(ids [][]byte, weights []float64, key []byte) []uint64 {
+	return SortByWeight(hashBytes(ids), weights, Hash(key))
+}
+
+// SortWithDistance receive nodes, hash and distance function, and sort it
+// by distance calculated by f instead of the default one, see DistanceFunc.
+// Nodes with equal distances are ordered by their hashes, see NodeHashTieBreak.
+func SortWithDistance(nodes []uint64, hash uint64, f DistanceFunc) []uint64 {
+	return toUint64s(distanceOrder(funcDistances(nodes, hash, f), NodeHashTieBreak(nodes)))
+}
+
+// SortByWeightWithDistance receive nodes, weights, hash and distance function,
+// and sort it by distance calculated by f * weight, see SortWithDistance.
+func SortByWeightWithDistance(nodes []uint64, weights []float64, hash uint64, f DistanceFunc) []uint64 {
+	return toUint64s(weightOrder(funcDistances(nodes, hash, f), weights, NodeHashTieBreak(nodes)))
+}
+
+func funcDistances(nodes []uint64, hash uint64, f DistanceFunc) []uint64 {
+	dist := make([]uint64, len(nodes))
+	for i := range nodes {
+		dist[i] = f(nodes[i], hash)
+	}
+	return dist
+}
+
+// NodeHashTieBreak returns tie-breaking function for SortWithTieBreak and
+// SortByWeightWithTieBreak ordering nodes by their hashes, so the result
+// depends on node hashes and the object hash only, not on the nodes order.
+// With the default distance only equal node hashes have equal distances,
+// custom DistanceFunc can produce more ties.
+func NodeHashTieBreak(nodes []uint64) func(i, j int) bool {
+	return func(i, j int) bool { return nodes[i] < nodes[j] }
+}
+
+// SortWithTieBreak receive nodes and hash, and sort it by distance.
+// Nodes with equal distances are ordered by less which receives
+// indices of nodes, so the result doesn't depend on the nodes order.
+func SortWithTieBreak(nodes []uint64, hash uint64, less func(i, j int) bool) []uint64 {
+	return toUint64s(sortByDistance(len(nodes), false, nodes, hash, less))
+}
+
+// SortByWeightWithTieBreak receive nodes, weights and hash, and sort it by distance * weight.
+// Nodes with equal scores are ordered by distance, nodes with equal scores and distances
+// (i.e. equal nodes with the same weight) are ordered by less which receives indices
+// of nodes, so the result doesn't depend on the nodes order.
+func SortByWeightWithTieBreak(nodes []uint64, weights []float64, hash uint64, less func(i, j int) bool) []uint64 {
+	return toUint64s(sortByWeight(len(nodes), false, nodes, weights, hash, less))
 }
 
 // SortSliceByValue received []T and hash to sort by value-distance
 func SortSliceByValue(slice interface{}, hash uint64) {
 	rule := prepareRule(slice)
 	if rule != nil {
-		swap := reflect.Swapper(slice)
-		sortByDistance(len(rule), false, rule, hash, swap)
+		permute(sortByDistance(len(rule), false, rule, hash, nil), reflect.Swapper(slice))
 	}
 }
 
@@ -87,23 +229,217 @@ func SortSliceByValue(slice interface{}, hash uint64) {
 func SortSliceByWeightValue(slice interface{}, weights []float64, hash uint64) {
 	rule := prepareRule(slice)
 	if rule != nil {
-		swap := reflect.Swapper(slice)
-		sortByWeight(reflect.ValueOf(slice).Len(), false, rule, weights, hash, swap)
+		ind := sortByWeight(reflect.ValueOf(slice).Len(), false, rule, weights, hash, nil)
+		permute(ind, reflect.Swapper(slice))
+	}
+}
+
+// SortedSliceByValue received []T and hash and returns its copy sorted
+// by value-distance, the slice itself is left intact.
+func SortedSliceByValue(slice interface{}, hash uint64) interface{} {
+	res := copySlice(slice)
+	SortSliceByValue(res, hash)
+	return res
+}
+
+// SortedSliceByWeightValue received []T, weights and hash and returns its
+// copy sorted by value-distance * weights, the slice itself is left intact.
+func SortedSliceByWeightValue(slice interface{}, weights []float64, hash uint64) interface{} {
+	res := copySlice(slice)
+	SortSliceByWeightValue(res, weights, hash)
+	return res
+}
+
+// SortMapKeys received map[K]V and hash and returns []K with keys of the
+// map sorted by value-distance, like SortSliceByValue sorts []K. nil is
+// returned if m is not a map or K is not supported by SortSliceByValue.
+func SortMapKeys(m interface{}, hash uint64) interface{} {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil
+	}
+
+	keys := reflect.MakeSlice(reflect.SliceOf(v.Type().Key()), v.Len(), v.Len())
+	for i, k := range v.MapKeys() {
+		keys.Index(i).Set(k)
+	}
+
+	res := keys.Interface()
+	rule := prepareRule(res)
+	if rule == nil && keys.Len() != 0 {
+		return nil
+	}
+	permute(sortByDistance(len(rule), false, rule, hash, nil), reflect.Swapper(res))
+	return res
+}
+
+// SortSliceIndicesByValue received []T and hash and returns indices of
+// its elements in the order of SortSliceByValue without moving them.
+// nil is returned for unsupported slices.
+func SortSliceIndicesByValue(slice interface{}, hash uint64) []uint64 {
+	rule := prepareRule(slice)
+	if rule == nil {
+		return nil
+	}
+	return toUint64s(sortByDistance(len(rule), false, rule, hash, nil))
+}
+
+// SortSliceIndicesByWeightValue received []T, weights and hash and returns
+// indices of its elements in the order of SortSliceByWeightValue without
+// moving them. nil is returned for unsupported slices.
+func SortSliceIndicesByWeightValue(slice interface{}, weights []float64, hash uint64) []uint64 {
+	rule := prepareRule(slice)
+	if rule == nil {
+		return nil
 	}
+	return toUint64s(sortByWeight(len(rule), false, rule, weights, hash, nil))
 }
 
 // SortSliceByIndex received []T and hash to sort by index-distance
 func SortSliceByIndex(slice interface{}, hash uint64) {
 	length := reflect.ValueOf(slice).Len()
-	swap := reflect.Swapper(slice)
-	sortByDistance(length, true, nil, hash, swap)
+	permute(sortByDistance(length, true, nil, hash, nil), reflect.Swapper(slice))
 }
 
 // SortSliceByWeightIndex received []T, weights and hash to sort by index-distance * weights
 func SortSliceByWeightIndex(slice interface{}, weights []float64, hash uint64) {
 	length := reflect.ValueOf(slice).Len()
-	swap := reflect.Swapper(slice)
-	sortByWeight(length, true, nil, weights, hash, swap)
+	permute(sortByWeight(length, true, nil, weights, hash, nil), reflect.Swapper(slice))
+}
+
+// SortSliceByHash received []T, precomputed hashes of its elements and hash
+// to sort by hash-distance. It's SortSliceByValue for callers which keep
+// node hashes between calls, hashes must be of the same length as the slice.
+func SortSliceByHash(slice interface{}, hashes []uint64, hash uint64) {
+	permute(sortByDistance(len(hashes), false, hashes, hash, nil), reflect.Swapper(slice))
+}
+
+// SortSliceByWeightHash received []T, precomputed hashes of its elements,
+// weights and hash to sort by hash-distance * weights, see SortSliceByHash.
+func SortSliceByWeightHash(slice interface{}, hashes []uint64, weights []float64, hash uint64) {
+	permute(sortByWeight(len(hashes), false, hashes, weights, hash, nil), reflect.Swapper(slice))
+}
+
+// SortSliceByFunc received []T, function returning hash of the i-th element
+// and hash to sort by hash-distance. It allows sorting elements of types
+// not implementing Hasher without wrapping them.
+func SortSliceByFunc(slice interface{}, nodeHash func(i int) uint64, hash uint64) {
+	SortSliceByHash(slice, funcHashes(slice, nodeHash), hash)
+}
+
+// SortSliceByWeightFunc received []T, function returning hash of the i-th
+// element, weights and hash to sort by hash-distance * weights.
+func SortSliceByWeightFunc(slice interface{}, nodeHash func(i int) uint64, weights []float64, hash uint64) {
+	SortSliceByWeightHash(slice, funcHashes(slice, nodeHash), weights, hash)
+}
+
+func funcHashes(slice interface{}, nodeHash func(i int) uint64) []uint64 {
+	hashes := make([]uint64, reflect.ValueOf(slice).Len())
+	for i := range hashes {
+		hashes[i] = nodeHash(i)
+	}
+	return hashes
+}
+
+// SortSliceByGroup received []T, group function and hash to sort by group-distance
+// and then by value-distance inside every group. group must return ID of the group
+// i-th element of the slice belongs to, groups are ordered by distance to the hash
+// of the group ID.
+func SortSliceByGroup(slice interface{}, group func(i int) string, hash uint64) {
+	sortSliceByGroup(slice, nil, group, hash)
+}
+
+// SortSliceByWeightGroup received []T, weights, group function and hash to sort
+// by group-distance and then by value-distance * weights inside every group,
+// see SortSliceByGroup.
+func SortSliceByWeightGroup(slice interface{}, weights []float64, group func(i int) string, hash uint64) {
+	sortSliceByGroup(slice, weights, group, hash)
+}
+
+func sortSliceByGroup(slice interface{}, weights []float64, group func(i int) string, hash uint64) {
+	rule := prepareRule(slice)
+	if rule == nil {
+		return
+	}
+
+	var (
+		l      = len(rule)
+		groups = make([]string, l)
+		gdist  = make(map[string]uint64)
+	)
+	for i := range groups {
+		groups[i] = group(i)
+		if _, ok := gdist[groups[i]]; !ok {
+			gdist[groups[i]] = distance(Hash([]byte(groups[i])), hash)
+		}
+	}
+
+	s, ind, dist := newSorter(l, false, rule, hash)
+	s.less = func(i, j int) bool {
+		ii, jj := ind[i], ind[j]
+		gi, gj := groups[ii], groups[jj]
+		if gi != gj {
+			if gdist[gi] != gdist[gj] {
+				return gdist[gi] < gdist[gj]
+			}
+			return gi < gj
+		}
+		if weights != nil {
+			return compareWeighted(dist[ii], dist[jj], weights[ii], weights[jj]) < 0
+		}
+		return dist[ii] < dist[jj]
+	}
+	sort.Sort(s)
+	permute(ind, reflect.Swapper(slice))
+}
+
+// SortSliceByMappedWeightValue received []T, weights keyed by node ID and hash to sort
+// by value-distance * weights. id must return ID of the i-th element of the slice.
+// An error is returned if there is no weight for some of the nodes, slice is left intact then.
+func SortSliceByMappedWeightValue(slice interface{}, weights map[string]float64, id func(i int) string, hash uint64) error {
+	ws := make([]float64, reflect.ValueOf(slice).Len())
+	for i := range ws {
+		var (
+			ok  bool
+			key = id(i)
+		)
+		if ws[i], ok = weights[key]; !ok {
+			return fmt.Errorf("missing weight for node %q", key)
+		}
+	}
+	SortSliceByWeightValue(slice, ws, hash)
+	return nil
+}
+
+// SortSliceByNormWeightValue received []T, raw weights, normalizer and hash
+// to sort by value-distance * normalized weights.
+func SortSliceByNormWeightValue(slice interface{}, weights []float64, norm normalizer.FloatNorm, hash uint64) {
+	SortSliceByWeightValue(slice, normalizer.Apply(norm, weights), hash)
+}
+
+// SortSliceByNormWeightIndex received []T, raw weights, normalizer and hash
+// to sort by index-distance * normalized weights.
+func SortSliceByNormWeightIndex(slice interface{}, weights []float64, norm normalizer.FloatNorm, hash uint64) {
+	SortSliceByWeightIndex(slice, normalizer.Apply(norm, weights), hash)
+}
+
+// SortSliceByNormWeightU64Value received []T, raw integer weights, normalizer
+// and hash to sort by value-distance * normalized weights. See
+// SortSliceByWeightU64Value for exact sorting by integer weights.
+func SortSliceByNormWeightU64Value(slice interface{}, weights []uint64, norm normalizer.Uint64Norm, hash uint64) {
+	SortSliceByWeightValue(slice, normalizer.ApplyU64(norm, weights), hash)
+}
+
+// SortSliceByAutoWeightValue received []T, raw weights and hash to sort by value-distance * weights.
+// Weights are normalized by their maximum, see normalizer.AutoMax.
+func SortSliceByAutoWeightValue(slice interface{}, weights []float64, hash uint64) {
+	SortSliceByNormWeightValue(slice, weights, normalizer.AutoMax(weights), hash)
+}
+
+// SortSliceByAutoWeightIndex received []T, raw weights and hash to sort by index-distance * weights.
+// Weights are normalized by their maximum, see normalizer.AutoMax.
+func SortSliceByAutoWeightIndex(slice interface{}, weights []float64, hash uint64) {
+	SortSliceByNormWeightIndex(slice, weights, normalizer.AutoMax(weights), hash)
 }
 
 func prepareRule(slice interface{}) []uint64 {
@@ -183,8 +519,10 @@ func prepareRule(slice interface{}) []uint64 {
 		}
 	case []string:
 		for i := 0; i < length; i++ {
-			rule = append(rule, Hash([]byte(slice[i])))
+			rule = append(rule, HashString(slice[i]))
 		}
+	case [][]byte:
+		rule = append(rule, hashBytes(slice)...)
 
 	default:
 		if _, ok := val.Index(0).Interface().(Hasher); !ok {
@@ -199,63 +537,162 @@ func prepareRule(slice interface{}) []uint64 {
 	return rule
 }
 
-// ValidateWeights checks if weights are normalized between 0.0 and 1.0
+// CompareScores compares positions of two nodes for the object following
+// the rules used by the sorting functions. It returns -1 if node A is placed
+// before node B, 1 if it is placed after and 0 if their order is not defined.
+// Distances are scaled by weights like SortByWeight does, nodes with equal
+// scores are ordered by distance, which matches unweighted sorting for nodes
+// with the same weight.
+func CompareScores(nodeHashA, nodeHashB, objectHash uint64, weightA, weightB float64) int {
+	return compareWeighted(distance(nodeHashA, objectHash), distance(nodeHashB, objectHash), weightA, weightB)
+}
+
+// Errors returned by ValidateWeights, they are wrapped with the index
+// of the first invalid weight.
+var (
+	ErrWeightNaN        = errors.New("weight is NaN")
+	ErrWeightOutOfRange = errors.New("weight is out of [0.0, 1.0] range")
+)
+
+// ValidateWeights checks if weights are normalized between 0.0 and 1.0.
+// Infinite weights are out of range.
 func ValidateWeights(weights []float64) error {
 	for i := range weights {
-		if math.IsNaN(weights[i]) || weights[i] > NormalizedMaxWeight || weights[i] < NormalizedMinWeight {
-			return errors.New("weights are not normalized")
+		var err error
+		if math.IsNaN(weights[i]) {
+			err = ErrWeightNaN
+		} else if weights[i] > NormalizedMaxWeight || weights[i] < NormalizedMinWeight {
+			err = ErrWeightOutOfRange
+		}
+		if err != nil {
+			return fmt.Errorf("weights are not normalized: weight %d: %w", i, err)
 		}
 	}
 	return nil
 }
 
-func newSorter(l int, byIndex bool, nodes []uint64, h uint64,
-	swap func(i, j int)) (*sorter, []int, []uint64) {
-	ind := make([]int, l)
+func newSorter(l int, byIndex bool, nodes []uint64, h uint64) (*sorter, []int, []uint64) {
+	dist := distances(l, byIndex, nodes, h)
+	s, ind := distSorter(dist)
+	return s, ind, dist
+}
+
+// distances returns distances from l nodes to h, see getDistance.
+func distances(l int, byIndex bool, nodes []uint64, h uint64) []uint64 {
 	dist := make([]uint64, l)
 	for i := 0; i < l; i++ {
-		ind[i] = i
 		dist[i] = getDistance(byIndex, i, nodes, h)
 	}
+	return dist
+}
+
+// distSorter returns sorter over identity permutation of dist indices.
+// less must be set by the caller.
+func distSorter(dist []uint64) (*sorter, []int) {
+	ind := make([]int, len(dist))
+	for i := range ind {
+		ind[i] = i
+	}
 
 	return &sorter{
-		l: l,
+		l: len(ind),
 		swap: func(i, j int) {
-			swap(i, j)
 			ind[i], ind[j] = ind[j], ind[i]
 		},
-	}, ind, dist
+	}, ind
 }
 
-// sortByWeight sorts nodes by weight using provided swapper.
+// sortByWeight returns permutation of node indices sorted by weight.
 // nodes contains hrw hashes. If it is nil, indices are used.
-func sortByWeight(l int, byIndex bool, nodes []uint64, weights []float64, hash uint64, swap func(i, j int)) {
+// If tie is not nil, it orders nodes with equal scores.
+func sortByWeight(l int, byIndex bool, nodes []uint64, weights []float64, hash uint64, tie func(i, j int) bool) []int {
+	return weightOrder(distances(l, byIndex, nodes, hash), weights, tie)
+}
+
+// weightOrder returns permutation of node indices sorted by weighted
+// scores of precomputed distances.
+func weightOrder(dist []uint64, weights []float64, tie func(i, j int) bool) []int {
 	// if all nodes have the same distance then sort uniformly
 	if allSameF64(weights) {
-		sortByDistance(l, byIndex, nodes, hash, swap)
-		return
+		return distanceOrder(dist, tie)
 	}
 
-	s, ind, dist := newSorter(l, byIndex, nodes, hash, swap)
+	s, ind := distSorter(dist)
 	s.less = func(i, j int) bool {
 		ii, jj := ind[i], ind[j]
-		// `maxUint64 - distance` makes the shorter distance more valuable
-		// it is necessary for operation with normalized values
-		wi := float64(^uint64(0)-dist[ii]) * weights[ii]
-		wj := float64(^uint64(0)-dist[jj]) * weights[jj]
-		return wi > wj // higher distance must be placed lower to be first
+		c := compareWeighted(dist[ii], dist[jj], weights[ii], weights[jj])
+		if c == 0 && tie != nil {
+			return tie(ii, jj)
+		}
+		return c < 0
 	}
 	sort.Sort(s)
+	return ind
+}
+
+// weightedScore returns score of the node for weighted sorting,
+// nodes with higher score are placed first.
+func weightedScore(dist uint64, weight float64) float64 {
+	// `maxUint64 - distance` makes the shorter distance more valuable
+	// it is necessary for operation with normalized values
+	return float64(^uint64(0)-dist) * weight
+}
+
+// compareWeighted returns -1 if the node with distance da and weight wa
+// is placed before the one with distance db and weight wb, 1 if it is
+// placed after and 0 if they are equal. Higher score is placed first,
+// nodes with equal scores are ordered by distance.
+func compareWeighted(da, db uint64, wa, wb float64) int {
+	sa, sb := weightedScore(da, wa), weightedScore(db, wb)
+	switch {
+	case sa > sb:
+		return -1
+	case sa < sb:
+		return 1
+	case da < db:
+		return -1
+	case da > db:
+		return 1
+	}
+	return 0
 }
 
-// sortByDistance sorts nodes by hrw distance using provided swapper.
+// sortByDistance returns permutation of node indices sorted by hrw distance.
 // nodes contains hrw hashes. If it is nil, indices are used.
-func sortByDistance(l int, byIndex bool, nodes []uint64, hash uint64, swap func(i, j int)) {
-	s, ind, dist := newSorter(l, byIndex, nodes, hash, swap)
+// If tie is not nil, it orders nodes with equal distances.
+func sortByDistance(l int, byIndex bool, nodes []uint64, hash uint64, tie func(i, j int) bool) []int {
+	return distanceOrder(distances(l, byIndex, nodes, hash), tie)
+}
+
+// distanceOrder returns permutation of node indices sorted by precomputed
+// distances.
+func distanceOrder(dist []uint64, tie func(i, j int) bool) []int {
+	s, ind := distSorter(dist)
 	s.less = func(i, j int) bool {
-		return dist[ind[i]] < dist[ind[j]]
+		ii, jj := ind[i], ind[j]
+		if dist[ii] == dist[jj] && tie != nil {
+			return tie(ii, jj)
+		}
+		return dist[ii] < dist[jj]
 	}
 	sort.Sort(s)
+	return ind
+}
+
+// permute reorders elements using provided swapper, so that i-th element
+// becomes the one which was at ind[i]. Every element is moved once at most,
+// ind is destroyed in the process.
+func permute(ind []int, swap func(i, j int)) {
+	for i := range ind {
+		cur := i
+		for ind[cur] != i {
+			next := ind[cur]
+			swap(cur, next)
+			ind[cur] = cur
+			cur = next
+		}
+		ind[cur] = cur
+	}
 }
 
 // getDistance return distance from nodes[i] to h.
@@ -274,6 +711,34 @@ func getDistance(byIndex bool, i int, nodes []uint64, h uint64) uint64 {
 	}
 }
 
+// copySlice returns shallow copy of the slice, anything else is
+// returned as is.
+func copySlice(slice interface{}) interface{} {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		return slice
+	}
+	res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(res, v)
+	return res.Interface()
+}
+
+func hashBytes(ids [][]byte) []uint64 {
+	hs := make([]uint64, len(ids))
+	for i := range ids {
+		hs[i] = Hash(ids[i])
+	}
+	return hs
+}
+
+func toUint64s(ind []int) []uint64 {
+	result := make([]uint64, len(ind))
+	for i := range ind {
+		result[i] = uint64(ind[i])
+	}
+	return result
+}
+
 func allSameF64(fs []float64) bool {
 	for i := range fs {
 		if fs[i] != fs[0] {
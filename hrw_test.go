@@ -1,13 +1,19 @@
 package hrw
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 
+	"github.com/nspcc-dev/hrw/normalizer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -82,6 +88,19 @@ func TestValidateWeights(t *testing.T) {
 	weights = []float64{1, 1, 1, 0.2, 0.2, 0.2}
 	err = ValidateWeights(weights)
 	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		weights []float64
+		err     error
+	}{
+		{[]float64{1, math.NaN()}, ErrWeightNaN},
+		{[]float64{1, 1.5}, ErrWeightOutOfRange},
+		{[]float64{-0.1}, ErrWeightOutOfRange},
+		{[]float64{0, math.Inf(1)}, ErrWeightOutOfRange},
+		{[]float64{math.Inf(-1)}, ErrWeightOutOfRange},
+	} {
+		require.True(t, errors.Is(ValidateWeights(tc.weights), tc.err), tc.weights)
+	}
 }
 
 func TestSortSliceByWeightIndex(t *testing.T) {
@@ -125,6 +144,114 @@ func TestSortSliceByValueFail(t *testing.T) {
 	})
 }
 
+func TestSortedSliceByValue(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []string{"a", "b", "c", "d", "e", "f"}
+		weights = []float64{1, 0.5, 0.2, 1, 0.3, 0.9}
+	)
+
+	sorted := SortedSliceByValue(nodes, hash)
+	require.Equal(t, []string{"d", "f", "c", "b", "a", "e"}, sorted)
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, nodes)
+
+	expect := append([]string{}, nodes...)
+	SortSliceByWeightValue(expect, weights, hash)
+	require.Equal(t, expect, SortedSliceByWeightValue(nodes, weights, hash))
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, nodes)
+
+	require.Equal(t, []int(nil), SortedSliceByValue([]int(nil), hash))
+	require.Equal(t, 10, SortedSliceByValue(10, hash))
+}
+
+func TestSortSliceIndicesByValue(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []string{"a", "b", "c", "d", "e", "f"}
+		weights = []float64{1, 0.5, 0.2, 1, 0.3, 0.9}
+	)
+
+	require.Equal(t, []uint64{3, 5, 2, 1, 0, 4}, SortSliceIndicesByValue(nodes, hash))
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, nodes)
+
+	ind := SortSliceIndicesByWeightValue(nodes, weights, hash)
+	sorted := SortedSliceByWeightValue(nodes, weights, hash).([]string)
+	for i := range ind {
+		require.Equal(t, sorted[i], nodes[ind[i]])
+	}
+
+	require.Nil(t, SortSliceIndicesByValue([]unknown{1, 2}, hash))
+	require.Nil(t, SortSliceIndicesByWeightValue(10, nil, hash))
+}
+
+func TestSortSliceByHash(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []string{"a", "b", "c", "d", "e", "f"}
+		hashes  = make([]uint64, len(nodes))
+		weights = []float64{1, 0.5, 0.2, 1, 0.3, 0.9}
+	)
+	for i := range nodes {
+		hashes[i] = Hash([]byte(nodes[i]))
+	}
+
+	actual := append([]string{}, nodes...)
+	SortSliceByHash(actual, hashes, hash)
+	require.Equal(t, []string{"d", "f", "c", "b", "a", "e"}, actual)
+
+	actual = append(actual[:0], nodes...)
+	SortSliceByWeightHash(actual, hashes, weights, hash)
+	require.Equal(t, SortedSliceByWeightValue(nodes, weights, hash), actual)
+
+	require.NotPanics(t, func() { SortSliceByHash([]string{}, nil, hash) })
+}
+
+func TestSortSliceByFunc(t *testing.T) {
+	type node struct{ addr string }
+
+	var (
+		hash    = Hash(testKey)
+		weights = []float64{1, 0.5, 0.2, 1, 0.3, 0.9}
+		names   = []string{"a", "b", "c", "d", "e", "f"}
+		nodes   = make([]node, len(names))
+	)
+	for i := range names {
+		nodes[i] = node{addr: names[i]}
+	}
+	nodeHash := func(i int) uint64 { return Hash([]byte(nodes[i].addr)) }
+
+	SortSliceByFunc(nodes, nodeHash, hash)
+	for i, n := range SortedSliceByValue(names, hash).([]string) {
+		require.Equal(t, n, nodes[i].addr)
+	}
+
+	for i := range names {
+		nodes[i] = node{addr: names[i]}
+	}
+	SortSliceByWeightFunc(nodes, nodeHash, weights, hash)
+	for i, n := range SortedSliceByWeightValue(names, weights, hash).([]string) {
+		require.Equal(t, n, nodes[i].addr)
+	}
+}
+
+func TestSortMapKeys(t *testing.T) {
+	var (
+		hash = Hash(testKey)
+		m    = map[string]int{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6}
+	)
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, []string{"d", "f", "c", "b", "a", "e"}, SortMapKeys(m, hash))
+	}
+
+	hs := map[hashString]struct{}{"a": {}, "b": {}, "c": {}}
+	require.Equal(t, SortedSliceByValue([]hashString{"a", "b", "c"}, hash), SortMapKeys(hs, hash))
+
+	require.Equal(t, []string{}, SortMapKeys(map[string]int{}, hash))
+	require.Nil(t, SortMapKeys(map[unknown]int{1: 1}, hash))
+	require.Nil(t, SortMapKeys([]string{"a"}, hash))
+}
+
 func TestSortSliceByValueHasher(t *testing.T) {
 	actual := []hashString{"a", "b", "c", "d", "e", "f"}
 	expect := []hashString{"d", "f", "c", "b", "a", "e"}
@@ -133,6 +260,93 @@ func TestSortSliceByValueHasher(t *testing.T) {
 	require.Equal(t, expect, actual)
 }
 
+func TestNewHash64(t *testing.T) {
+	data := []byte(strings.Repeat("manifest entry;", 100))
+
+	h := NewHash64()
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err := h.Write(data[i:end])
+		require.NoError(t, err)
+	}
+	require.Equal(t, Hash(data), h.Hash())
+	require.Equal(t, Hash(nil), NewHash64().Hash())
+
+	hash, err := HashReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, Hash(data), hash)
+
+	_, err = HashReader(iotest.TimeoutReader(bytes.NewReader(data)))
+	require.Error(t, err)
+}
+
+func TestHashString(t *testing.T) {
+	for _, s := range []string{"", "a", "localhost:8080", string(testKey)} {
+		require.Equal(t, Hash([]byte(s)), HashString(s))
+	}
+
+	key := strings.Repeat("key", 100)
+	require.Zero(t, testing.AllocsPerRun(10, func() { HashString(key) }))
+}
+
+func TestHash64(t *testing.T) {
+	fnvOf := func(s string) Hash64 {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s))
+		return Hash64{h}
+	}
+
+	var (
+		key   = fnvOf(string(testKey))
+		nodes = []uint64{1, 2, 3, 4, 5, 6}
+	)
+	require.Equal(t, key.Sum64(), key.Hash())
+	require.Equal(t, Sort(nodes, key.Sum64()), Sort(nodes, key.Hash()))
+
+	actual := []Hash64{fnvOf("a"), fnvOf("b"), fnvOf("c"), fnvOf("d")}
+	hashes := make([]uint64, len(actual))
+	for i := range actual {
+		hashes[i] = actual[i].Sum64()
+	}
+	expect := make([]uint64, len(actual))
+	for i, j := range Sort(hashes, key.Hash()) {
+		expect[i] = hashes[j]
+	}
+
+	SortSliceByValue(actual, key.Hash())
+	for i := range actual {
+		require.Equal(t, expect[i], actual[i].Hash())
+	}
+}
+
+func TestSortBytes(t *testing.T) {
+	ids := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e"), []byte("f")}
+	require.Equal(t, []uint64{3, 5, 2, 1, 0, 4}, SortBytes(ids, testKey))
+
+	t.Run("slice", func(t *testing.T) {
+		actual := append([][]byte{}, ids...)
+		SortSliceByValue(actual, Hash(testKey))
+		require.Equal(t, [][]byte{ids[3], ids[5], ids[2], ids[1], ids[0], ids[4]}, actual)
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		var (
+			weights = []float64{1, 0.2, 0.5, 0.1, 1, 0.7}
+			values  = []string{"a", "b", "c", "d", "e", "f"}
+			expect  = make([]string, len(values))
+		)
+		copy(expect, values)
+		SortSliceByWeightValue(expect, weights, Hash(testKey))
+
+		for i, j := range SortBytesByWeight(ids, weights, testKey) {
+			require.Equal(t, expect[i], values[j])
+		}
+	})
+}
+
 func TestSortSliceByValueIntSlice(t *testing.T) {
 	cases := []slices{
 		{
@@ -206,6 +420,100 @@ func TestSort(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestSortSeeded(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 10)
+		weights = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+		same    int
+		total   = 1000
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+		weights[i] = float64(i+1) / 10
+	}
+
+	for i := 0; i < total; i++ {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		hash := Hash(key)
+
+		a := SortSeeded(nodes, hash, 1)
+		require.Equal(t, a, SortSeeded(nodes, hash, 1))
+		require.Equal(t, Sort(nodes, SeedHash(hash, 1)), a)
+		require.Equal(t, SortByWeight(nodes, weights, SeedHash(hash, 2)), SortByWeightSeeded(nodes, weights, hash, 2))
+		if a[0] == SortSeeded(nodes, hash, 2)[0] {
+			same++
+		}
+	}
+	// independent orderings share the first node for ~1/len(nodes) of keys
+	require.InDelta(t, total/len(nodes), same, float64(total)/20)
+}
+
+func TestSortWithDistance(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = make([]uint64, 10)
+		weights = make([]float64, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+		weights[i] = float64(i+1) / 10
+	}
+
+	require.Equal(t, Sort(nodes, hash), SortWithDistance(nodes, hash, Distance))
+	require.Equal(t, SortByWeight(nodes, weights, hash), SortByWeightWithDistance(nodes, weights, hash, Distance))
+
+	res, err := Select(nodes).Distance(DistanceV2).For(hash)
+	require.NoError(t, err)
+	require.Equal(t, res, SortWithDistance(nodes, hash, DistanceV2))
+
+	res, err = Select(nodes).Weigh(func(i int) float64 { return weights[i] }).Distance(DistanceV2).For(hash)
+	require.NoError(t, err)
+	require.Equal(t, res, SortByWeightWithDistance(nodes, weights, hash, DistanceV2))
+}
+
+func TestNodeHashTieBreak(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 50)
+		// only 4 distinct distances, so there are a lot of ties
+		coarse = func(node, object uint64) uint64 { return distance(node, object) >> 62 }
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	hashesOf := func(nodes []uint64, ind []uint64) []uint64 {
+		res := make([]uint64, len(ind))
+		for i := range ind {
+			res[i] = nodes[ind[i]]
+		}
+		return res
+	}
+
+	expect := hashesOf(nodes, SortWithDistance(nodes, hash, coarse))
+	for i := 1; i < len(expect); i++ {
+		a, b := coarse(expect[i-1], hash), coarse(expect[i], hash)
+		require.True(t, a < b || a == b && expect[i-1] < expect[i])
+	}
+	require.Equal(t, hashesOf(nodes, Sort(nodes, hash)), hashesOf(nodes, SortWithTieBreak(nodes, hash, NodeHashTieBreak(nodes))))
+
+	for i := 0; i < 10; i++ {
+		shuffled := append([]uint64{}, nodes...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		require.Equal(t, expect, hashesOf(shuffled, SortWithDistance(shuffled, hash, coarse)))
+
+		res, err := Select(shuffled).Distance(coarse).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, expect, hashesOf(shuffled, res))
+
+		res, err = Select(shuffled).Distance(coarse).Adjust(func(int) float64 { return 0 }).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, expect, hashesOf(shuffled, res))
+	}
+}
+
 func TestDistribution(t *testing.T) {
 	const (
 		size    = 10
@@ -420,7 +728,9 @@ func TestDistribution(t *testing.T) {
 			w[i] = float64(size-i) / float64(size)
 		}
 
-		rand.Shuffle(size, func(i, j int) {
+		// fixed seed: the smallest weights get no keys at all, so the
+		// check below fails if the shuffle puts them next to each other
+		rand.New(rand.NewSource(1)).Shuffle(size, func(i, j int) {
 			w[i], w[j] = w[j], w[i]
 		})
 		for i = 0; i < keys; i++ {
@@ -781,3 +1091,271 @@ func benchmarkSortByWeightValue(b *testing.B, n int, hash uint64) {
 		SortSliceByWeightValue(servers, weights, hash)
 	}
 }
+
+func TestSortSliceByAutoWeight(t *testing.T) {
+	hash := Hash(testKey)
+
+	t.Run("value", func(t *testing.T) {
+		actual := []string{"a", "b", "c", "d", "e", "f"}
+		expect := []string{"a", "b", "c", "d", "e", "f"}
+		SortSliceByWeightValue(expect, []float64{1, 1, 1, 0.2, 0.2, 0.2}, hash)
+		SortSliceByAutoWeightValue(actual, []float64{50, 50, 50, 10, 10, 10}, hash)
+		require.Equal(t, expect, actual)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		actual := []string{"a", "b", "c", "d", "e", "f"}
+		expect := []string{"a", "c", "b", "e", "f", "d"}
+		SortSliceByAutoWeightIndex(actual, []float64{50, 50, 50, 10, 10, 10}, hash)
+		require.Equal(t, expect, actual)
+	})
+}
+
+func TestSortSliceByNormWeight(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		weights = []float64{100, 100, 100, 20, 20, 20}
+		norm    = normalizer.NewMaxF64(100)
+	)
+
+	t.Run("value", func(t *testing.T) {
+		actual := []string{"a", "b", "c", "d", "e", "f"}
+		expect := []string{"a", "b", "c", "d", "e", "f"}
+		SortSliceByWeightValue(expect, []float64{1, 1, 1, 0.2, 0.2, 0.2}, hash)
+		SortSliceByNormWeightValue(actual, weights, norm, hash)
+		require.Equal(t, expect, actual)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		actual := []string{"a", "b", "c", "d", "e", "f"}
+		expect := []string{"a", "c", "b", "e", "f", "d"}
+		SortSliceByNormWeightIndex(actual, weights, norm, hash)
+		require.Equal(t, expect, actual)
+	})
+}
+
+func TestSortSliceByMappedWeightValue(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		id      = func(s []string) func(int) string { return func(i int) string { return s[i] } }
+		weights = map[string]float64{"a": 1, "b": 1, "c": 1, "d": 0.2, "e": 0.2, "f": 0.2}
+	)
+
+	t.Run("any order", func(t *testing.T) {
+		expect := []string{"a", "b", "c", "d", "e", "f"}
+		SortSliceByWeightValue(expect, []float64{1, 1, 1, 0.2, 0.2, 0.2}, hash)
+
+		actual := []string{"f", "e", "d", "c", "b", "a"}
+		require.NoError(t, SortSliceByMappedWeightValue(actual, weights, id(actual), hash))
+		require.Equal(t, expect, actual)
+	})
+
+	t.Run("missing weight", func(t *testing.T) {
+		actual := []string{"a", "b", "x"}
+		require.Error(t, SortSliceByMappedWeightValue(actual, weights, id(actual), hash))
+		require.Equal(t, []string{"a", "b", "x"}, actual)
+	})
+}
+
+func TestSortByWeight(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4, 5, 6}
+		weights = []float64{1, 1, 1, 0.2, 0.2, 0.2}
+	)
+
+	actual := SortByWeight(nodes, weights, hash)
+	require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, nodes)
+	// heavy nodes by distance, then light nodes with the best weighted scores
+	require.Equal(t, []uint64{1, 2, 0, 5, 3, 4}, actual)
+
+	t.Run("uniform weights", func(t *testing.T) {
+		require.Equal(t, Sort(nodes, hash), SortByWeight(nodes, []float64{1, 1, 1, 1, 1, 1}, hash))
+	})
+
+	t.Run("zero weights", func(t *testing.T) {
+		require.Equal(t, Sort(nodes, hash), SortByWeight(nodes, make([]float64, len(nodes)), hash))
+	})
+}
+
+func TestPermute(t *testing.T) {
+	for n := 0; n < 50; n++ {
+		var (
+			ind      = rand.Perm(n)
+			values   = make([]int, n)
+			expected = make([]int, n)
+			swaps    int
+		)
+		for i := range values {
+			values[i] = i * 10
+		}
+		for i := range ind {
+			expected[i] = values[ind[i]]
+		}
+
+		permute(ind, func(i, j int) {
+			swaps++
+			values[i], values[j] = values[j], values[i]
+		})
+		require.Equal(t, expected, values)
+		require.True(t, swaps < n || n == 0)
+	}
+}
+
+func TestCompareScores(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = []uint64{1, 2, 3, 4, 5, 6}
+		weights = []float64{1, 0.3, 1, 0.2, 0.7, 0.2}
+	)
+
+	check := func(t *testing.T, nodes, order []uint64, weights []float64) {
+		for i := 0; i < len(order)-1; i++ {
+			a, b := order[i], order[i+1]
+			require.Equal(t, -1, CompareScores(nodes[a], nodes[b], hash, weights[a], weights[b]))
+			require.Equal(t, 1, CompareScores(nodes[b], nodes[a], hash, weights[b], weights[a]))
+		}
+	}
+
+	t.Run("unweighted", func(t *testing.T) {
+		uniform := []float64{1, 1, 1, 1, 1, 1}
+		check(t, nodes, Sort(nodes, hash), uniform)
+		require.Equal(t, 0, CompareScores(1, 1, hash, 0.5, 0.5))
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		check(t, nodes, SortByWeight(nodes, weights, hash), weights)
+		require.Equal(t, 1, CompareScores(1, 2, hash, 0, 0.01))
+		require.Equal(t, -1, CompareScores(1, 2, hash, 0.01, 0))
+	})
+
+	t.Run("near tie", func(t *testing.T) {
+		// scores of the first two nodes are equal after rounding
+		nodes := []uint64{nodeAt(1<<40+1, hash), nodeAt(1<<40, hash), nodeAt(1<<62, hash)}
+		weights := []float64{0.5, 0.5, 0.3}
+		require.Equal(t, weightedScore(1<<40+1, 0.5), weightedScore(1<<40, 0.5))
+
+		order := SortByWeight(nodes, weights, hash)
+		require.Equal(t, []uint64{1, 0, 2}, order)
+		check(t, nodes, order, weights)
+
+		zeros := []float64{0, 0, 1}
+		order = SortByWeight(nodes, zeros, hash)
+		require.Equal(t, []uint64{2, 1, 0}, order)
+		check(t, nodes, order, zeros)
+	})
+}
+
+// nodeAt returns node hash which has distance d to hash.
+func nodeAt(d, hash uint64) uint64 {
+	// invert mmh3 64 bit finalizer used by distance
+	d ^= d >> 33
+	d *= 0x9cb4b2f8129337db
+	d ^= d >> 33
+	d *= 0x4f74430c22a54005
+	d ^= d >> 33
+	return d ^ hash
+}
+
+func TestSortWithTieBreak(t *testing.T) {
+	var (
+		hash = Hash(testKey)
+		ids  = []int{30, 10, 20, 40}
+		less = func(i, j int) bool { return ids[i] < ids[j] }
+	)
+
+	t.Run("equal nodes", func(t *testing.T) {
+		nodes := []uint64{7, 7, 7, 1}
+		actual := SortWithTieBreak(nodes, hash, less)
+
+		// equal nodes are ordered by id wherever they are placed
+		var sevens []uint64
+		for _, i := range actual {
+			if nodes[i] == 7 {
+				sevens = append(sevens, i)
+			}
+		}
+		require.Equal(t, []uint64{1, 2, 0}, sevens)
+	})
+
+	t.Run("zero weights", func(t *testing.T) {
+		nodes := []uint64{7, 7, 7, 1}
+		weights := []float64{0, 0, 0, 1}
+		actual := SortByWeightWithTieBreak(nodes, weights, hash, less)
+		require.Equal(t, []uint64{3, 1, 2, 0}, actual)
+	})
+}
+
+func TestSortSliceByGroup(t *testing.T) {
+	var (
+		hash   = Hash(testKey)
+		actual = []string{"a1", "b1", "a2", "c1", "b2", "c2", "a3"}
+		groups = make(map[string]string)
+	)
+	for _, v := range actual {
+		groups[v] = v[:1]
+	}
+	group := func(s []string) func(int) string { return func(i int) string { return groups[s[i]] } }
+
+	SortSliceByGroup(actual, group(actual), hash)
+
+	// groups go one after another in HRW order
+	order := []string{"a", "b", "c"}
+	SortSliceByValue(order, hash)
+	var (
+		pos    int
+		expect []string
+	)
+	for _, g := range order {
+		var members []string
+		for ; pos < len(actual) && groups[actual[pos]] == g; pos++ {
+			members = append(members, actual[pos])
+		}
+		require.NotEmpty(t, members)
+
+		sorted := append([]string{}, members...)
+		SortSliceByValue(sorted, hash)
+		require.Equal(t, sorted, members)
+		expect = append(expect, members...)
+	}
+	require.Equal(t, expect, actual)
+
+	t.Run("input order", func(t *testing.T) {
+		shuffled := []string{"c2", "a3", "b2", "a1", "c1", "b1", "a2"}
+		SortSliceByGroup(shuffled, group(shuffled), hash)
+		require.Equal(t, actual, shuffled)
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		var (
+			values  = []string{"a1", "b1", "a2", "c1", "b2", "c2", "a3"}
+			weights = map[string]float64{"a1": 1, "b1": 0.5, "a2": 0.2, "c1": 1, "b2": 1, "c2": 0.3, "a3": 0.7}
+			ws      = make([]float64, len(values))
+		)
+		for i := range values {
+			ws[i] = weights[values[i]]
+		}
+		SortSliceByWeightGroup(values, ws, group(values), hash)
+
+		// groups keep their order, members are sorted by weighted score
+		pos := 0
+		for _, g := range order {
+			var members, mws []string
+			for ; pos < len(values) && groups[values[pos]] == g; pos++ {
+				members = append(members, values[pos])
+				mws = append(mws, values[pos])
+			}
+			sorted := make([]float64, len(mws))
+			for i := range mws {
+				sorted[i] = weights[mws[i]]
+			}
+			SortSliceByWeightValue(mws, sorted, hash)
+			require.Equal(t, mws, members)
+		}
+		require.Equal(t, len(values), pos)
+
+		uniform := []float64{1, 1, 1, 1, 1, 1, 1}
+		SortSliceByWeightGroup(values, uniform, group(values), hash)
+		require.Equal(t, actual, values)
+	})
+}
@@ -0,0 +1,124 @@
+// Package hrwtest provides helpers for testing HRW placement stability
+// in packages using hrw.
+package hrwtest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/hrw"
+)
+
+// UpdateEnv is the name of the environment variable which makes
+// AssertStable rewrite golden files instead of checking them.
+const UpdateEnv = "HRW_UPDATE_GOLDEN"
+
+// maxReported is the maximum number of changed keys reported.
+const maxReported = 10
+
+// Config describes placement to check.
+type Config struct {
+	// Nodes contains node IDs hashed with hrw.Hash.
+	Nodes []string
+	// Weights contains normalized node weights, nodes are sorted
+	// by distance if empty.
+	Weights []float64
+	// Keys contains corpus of object keys hashed with hrw.Hash.
+	Keys []string
+	// Replicas is the number of nodes recorded for every key,
+	// all nodes are recorded if it is zero.
+	Replicas int
+}
+
+// AssertStable records placement of every key from the corpus into the
+// golden file if UpdateEnv variable is set and fails the test if the
+// placement differs from the recorded one otherwise. Missing golden file
+// fails the test too, so it must be created with UpdateEnv and committed.
+// It makes accidental placement-breaking changes of both the library
+// and the configuration visible.
+func AssertStable(t testing.TB, cfg Config, golden string) {
+	t.Helper()
+
+	actual := Placement(cfg)
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := ioutil.WriteFile(golden, actual, 0644); err != nil {
+			t.Fatalf("can't write golden file: %v", err)
+		}
+		t.Logf("golden file %s is written", golden)
+		return
+	}
+
+	data, err := ioutil.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s doesn't exist (set %s=1 to create it)", golden, UpdateEnv)
+		return
+	} else if err != nil {
+		t.Fatalf("can't read golden file: %v", err)
+		return
+	}
+
+	if diff := diffLines(data, actual); len(diff) != 0 {
+		t.Errorf("placement differs from %s (set %s=1 to update it):\n%s",
+			golden, UpdateEnv, strings.Join(diff, "\n"))
+	}
+}
+
+// Placement returns text representation of the placement stored in golden
+// files: one line per key containing the key and comma-separated node IDs.
+func Placement(cfg Config) []byte {
+	hashes := make([]uint64, len(cfg.Nodes))
+	for i := range cfg.Nodes {
+		hashes[i] = hrw.Hash([]byte(cfg.Nodes[i]))
+	}
+
+	n := cfg.Replicas
+	if n <= 0 || n > len(cfg.Nodes) {
+		n = len(cfg.Nodes)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for _, key := range cfg.Keys {
+		var (
+			hash  = hrw.Hash([]byte(key))
+			order []uint64
+		)
+		if len(cfg.Weights) != 0 {
+			order = hrw.SortByWeight(hashes, cfg.Weights, hash)
+		} else {
+			order = hrw.Sort(hashes, hash)
+		}
+
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = cfg.Nodes[order[i]]
+		}
+		fmt.Fprintf(buf, "%s\t%s\n", key, strings.Join(ids, ","))
+	}
+	return buf.Bytes()
+}
+
+func diffLines(expected, actual []byte) []string {
+	var (
+		diff []string
+		es   = bufio.NewScanner(bytes.NewReader(expected))
+		as   = bufio.NewScanner(bytes.NewReader(actual))
+	)
+	for {
+		eok, aok := es.Scan(), as.Scan()
+		if !eok && !aok {
+			return diff
+		}
+		if es.Text() != as.Text() {
+			if len(diff) == maxReported {
+				return append(diff, "...")
+			}
+			diff = append(diff, fmt.Sprintf("- %s\n+ %s", es.Text(), as.Text()))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package hrwtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// Fatalf records the error without stopping the test, AssertStable
+// returns right after it.
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) Logf(string, ...interface{}) {}
+
+func TestAssertStable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hrwtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Nodes:    []string{"a", "b", "c", "d"},
+		Replicas: 2,
+	}
+	for i := 0; i < 50; i++ {
+		cfg.Keys = append(cfg.Keys, "key-"+strconv.Itoa(i))
+	}
+	golden := filepath.Join(dir, "placement.golden")
+
+	t.Run("missing file", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertStable(r, cfg, golden)
+		require.Len(t, r.errors, 1)
+		_, err := os.Stat(golden)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	require.NoError(t, os.Setenv(UpdateEnv, "1"))
+	r := &recorder{TB: t}
+	AssertStable(r, cfg, golden)
+	require.NoError(t, os.Unsetenv(UpdateEnv))
+	require.Empty(t, r.errors)
+	require.FileExists(t, golden)
+
+	AssertStable(r, cfg, golden)
+	require.Empty(t, r.errors)
+
+	t.Run("changed config", func(t *testing.T) {
+		changed := cfg
+		changed.Weights = []float64{1, 0.1, 0.1, 0.1}
+
+		r := &recorder{TB: t}
+		AssertStable(r, changed, golden)
+		require.Len(t, r.errors, 1)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		changed := cfg
+		changed.Nodes = []string{"a", "b", "c", "e"}
+
+		require.NoError(t, os.Setenv(UpdateEnv, "1"))
+		r := &recorder{TB: t}
+		AssertStable(r, changed, golden)
+		require.NoError(t, os.Unsetenv(UpdateEnv))
+		require.Empty(t, r.errors)
+
+		AssertStable(r, changed, golden)
+		require.Empty(t, r.errors)
+	})
+}
+
+func TestPlacement(t *testing.T) {
+	cfg := Config{Nodes: []string{"a", "b", "c"}, Keys: []string{"x", "y"}}
+
+	lines := Placement(cfg)
+	require.Equal(t, lines, Placement(cfg))
+	require.Regexp(t, "^x\t[abc],[abc],[abc]\ny\t[abc],[abc],[abc]\n$", string(lines))
+
+	cfg.Replicas = 1
+	require.Regexp(t, "^x\t[abc]\ny\t[abc]\n$", string(Placement(cfg)))
+}
@@ -0,0 +1,37 @@
+package hrw
+
+import "sync"
+
+// Interner caches hashes of node identities, so large membership lists
+// re-created often (e.g. on every configuration refresh) don't rehash
+// every node. Hashes are computed with Hash once per distinct identity.
+// It is safe for concurrent use, zero value is ready to use.
+type Interner struct {
+	m sync.Map
+}
+
+// HashString returns hash of the node identity.
+func (in *Interner) HashString(id string) uint64 {
+	if h, ok := in.m.Load(id); ok {
+		return h.(uint64)
+	}
+	h := HashString(id)
+	in.m.Store(id, h)
+	return h
+}
+
+// HashStrings returns hashes of node identities suitable for Sort.
+func (in *Interner) HashStrings(ids []string) []uint64 {
+	hs := make([]uint64, len(ids))
+	for i := range ids {
+		hs[i] = in.HashString(ids[i])
+	}
+	return hs
+}
+
+// Forget removes cached hashes of identities which are no longer used.
+func (in *Interner) Forget(ids ...string) {
+	for i := range ids {
+		in.m.Delete(ids[i])
+	}
+}
@@ -0,0 +1,36 @@
+package hrw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterner(t *testing.T) {
+	var (
+		in  Interner
+		ids = []string{"a", "b", "c"}
+	)
+
+	hs := in.HashStrings(ids)
+	for i := range ids {
+		require.Equal(t, Hash([]byte(ids[i])), hs[i])
+	}
+	require.Equal(t, hs, in.HashStrings(ids))
+	require.Equal(t, SortBytes([][]byte{[]byte("a"), []byte("b"), []byte("c")}, testKey), Sort(hs, Hash(testKey)))
+
+	in.Forget("a")
+	_, ok := in.m.Load("a")
+	require.False(t, ok)
+	require.Equal(t, hs[0], in.HashString("a"))
+
+	t.Run("concurrent", func(t *testing.T) {
+		res := make(chan []uint64)
+		for i := 0; i < 4; i++ {
+			go func() { res <- in.HashStrings(ids) }()
+		}
+		for i := 0; i < 4; i++ {
+			require.Equal(t, hs, <-res)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package hrw
+
+import "sync"
+
+// distPool keeps distance buffers of pooled sorting functions.
+var distPool = sync.Pool{New: func() interface{} { return new([]uint64) }}
+
+// SortInto is Sort storing the result into dst and distances into scratch.
+// Both are reused if they have enough capacity for all nodes, otherwise
+// new ones are allocated, so callers keeping buffers between calls sort
+// without heap allocations. The result is dst[:len(nodes)].
+func SortInto(dst, scratch, nodes []uint64, hash uint64) []uint64 {
+	ind, dist := prepareInto(dst, scratch, nodes, hash)
+	heapSort(ind, func(a, b uint64) bool { return dist[a] < dist[b] })
+	return ind
+}
+
+// SortByWeightInto is SortByWeight storing the result into dst and
+// distances into scratch, see SortInto.
+func SortByWeightInto(dst, scratch, nodes []uint64, weights []float64, hash uint64) []uint64 {
+	ind, dist := prepareInto(dst, scratch, nodes, hash)
+	if allSameF64(weights) {
+		heapSort(ind, func(a, b uint64) bool { return dist[a] < dist[b] })
+	} else {
+		heapSort(ind, func(a, b uint64) bool {
+			return compareWeighted(dist[a], dist[b], weights[a], weights[b]) < 0
+		})
+	}
+	return ind
+}
+
+// SortPooled is Sort taking the distance buffer from the package-level
+// sync.Pool, so only the result is allocated. It is meant for hot paths
+// doing many sorts where callers don't want to keep buffers themselves.
+func SortPooled(nodes []uint64, hash uint64) []uint64 {
+	buf := getDist(len(nodes))
+	res := SortInto(make([]uint64, len(nodes)), *buf, nodes, hash)
+	distPool.Put(buf)
+	return res
+}
+
+// SortByWeightPooled is SortByWeight taking the distance buffer from the
+// package-level sync.Pool, see SortPooled.
+func SortByWeightPooled(nodes []uint64, weights []float64, hash uint64) []uint64 {
+	buf := getDist(len(nodes))
+	res := SortByWeightInto(make([]uint64, len(nodes)), *buf, nodes, weights, hash)
+	distPool.Put(buf)
+	return res
+}
+
+// getDist takes buffer from the pool growing it to hold l distances, so
+// the buffer used for sorting is the one returned to the pool.
+func getDist(l int) *[]uint64 {
+	buf := distPool.Get().(*[]uint64)
+	if cap(*buf) < l {
+		*buf = make([]uint64, l)
+	}
+	return buf
+}
+
+func prepareInto(dst, scratch, nodes []uint64, hash uint64) ([]uint64, []uint64) {
+	l := len(nodes)
+	if cap(dst) < l {
+		dst = make([]uint64, l)
+	}
+	if cap(scratch) < l {
+		scratch = make([]uint64, l)
+	}
+	dst, scratch = dst[:l], scratch[:l]
+	for i := range nodes {
+		dst[i] = uint64(i)
+		scratch[i] = distance(nodes[i], hash)
+	}
+	return dst, scratch
+}
+
+// heapSort sorts node indices in place. Unlike sort package it doesn't
+// need an interface value or a swapper, so it doesn't allocate.
+func heapSort(ind []uint64, less func(a, b uint64) bool) {
+	n := len(ind)
+	for i := n/2 - 1; i >= 0; i-- {
+		siftDown(ind, i, n, less)
+	}
+	for end := n - 1; end > 0; end-- {
+		ind[0], ind[end] = ind[end], ind[0]
+		siftDown(ind, 0, end, less)
+	}
+}
+
+func siftDown(ind []uint64, root, n int, less func(a, b uint64) bool) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && less(ind[child], ind[child+1]) {
+			child++
+		}
+		if !less(ind[root], ind[child]) {
+			return
+		}
+		ind[root], ind[child] = ind[child], ind[root]
+		root = child
+	}
+}
@@ -0,0 +1,88 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortInto(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 100)
+		weights = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+		dst     []uint64
+		scratch = make([]uint64, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for k := uint64(0); k < 100; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		dst = SortInto(dst, scratch, nodes, hash)
+		require.Equal(t, Sort(nodes, hash), dst)
+		dst = SortByWeightInto(dst, scratch, nodes, weights, hash)
+		require.Equal(t, SortByWeight(nodes, weights, hash), dst)
+	}
+
+	require.Equal(t, Sort(nodes[:5], 1), SortInto(nil, nil, nodes[:5], 1))
+	require.Empty(t, SortInto(dst, scratch, nil, 1))
+
+	same := make([]float64, len(nodes))
+	require.Equal(t, Sort(nodes, 1), SortByWeightInto(dst, scratch, nodes, same, 1))
+
+	t.Run("allocations", func(t *testing.T) {
+		hash := Hash(testKey)
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			dst = SortInto(dst, scratch, nodes, hash)
+		}))
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			dst = SortByWeightInto(dst, scratch, nodes, weights, hash)
+		}))
+	})
+}
+
+func BenchmarkSortInto_fnv_1000(b *testing.B) {
+	var (
+		hash    = Hash(testKey)
+		servers = make([]uint64, 1000)
+		dst     = make([]uint64, len(servers))
+		scratch = make([]uint64, len(servers))
+	)
+	for i := range servers {
+		servers[i] = uint64(i)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		dst = SortInto(dst, scratch, servers, hash)
+	}
+}
+
+func TestSortPooled(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 100)
+		weights = make([]float64, len(nodes))
+		hash    = Hash(testKey)
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for _, l := range []int{0, 10, 100, 5} {
+		require.Equal(t, Sort(nodes[:l], hash), SortPooled(nodes[:l], hash))
+		require.Equal(t, SortByWeight(nodes[:l], weights[:l], hash), SortByWeightPooled(nodes[:l], weights[:l], hash))
+	}
+
+	// only the result is allocated once the buffer is pooled
+	require.True(t, testing.AllocsPerRun(10, func() { SortPooled(nodes, hash) }) <= 1)
+}
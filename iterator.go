@@ -0,0 +1,42 @@
+package hrw
+
+import "container/heap"
+
+// Iterator yields node indices in the order of Sort (or SortByWeight)
+// lazily: preparing it costs O(n) and every Next costs O(log(n)), so
+// callers stopping after the first suitable node (e.g. in retry loops)
+// don't pay for sorting all of them.
+type Iterator struct {
+	h indexHeap
+}
+
+// NewIterator returns Iterator over nodes in the order of Sort.
+func NewIterator(nodes []uint64, hash uint64) *Iterator {
+	dist := distances(len(nodes), false, nodes, hash)
+	return newIterator(len(dist), func(i, j int) bool { return dist[i] < dist[j] })
+}
+
+// NewWeightIterator returns Iterator over nodes in the order of
+// SortByWeight.
+func NewWeightIterator(nodes []uint64, weights []float64, hash uint64) *Iterator {
+	dist := distances(len(nodes), false, nodes, hash)
+	return newIterator(len(dist), weightLess(dist, weights))
+}
+
+func newIterator(l int, less func(i, j int) bool) *Iterator {
+	it := &Iterator{h: indexHeap{ind: make([]int, l), less: less}}
+	for i := range it.h.ind {
+		it.h.ind[i] = i
+	}
+	heap.Init(&it.h)
+	return it
+}
+
+// Next returns index of the next node. It returns false when there
+// are no nodes left.
+func (it *Iterator) Next() (uint64, bool) {
+	if it.h.Len() == 0 {
+		return 0, false
+	}
+	return uint64(heap.Pop(&it.h).(int)), true
+}
@@ -0,0 +1,39 @@
+package hrw
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterator(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = make([]uint64, 50)
+		weights = make([]float64, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	collect := func(it *Iterator) []uint64 {
+		var res []uint64
+		for i, ok := it.Next(); ok; i, ok = it.Next() {
+			res = append(res, i)
+		}
+		_, ok := it.Next()
+		require.False(t, ok)
+		return res
+	}
+
+	require.Equal(t, Sort(nodes, hash), collect(NewIterator(nodes, hash)))
+	require.Equal(t, SortByWeight(nodes, weights, hash), collect(NewWeightIterator(nodes, weights, hash)))
+	require.Empty(t, collect(NewIterator(nil, hash)))
+
+	it := NewIterator(nodes, hash)
+	first, ok := it.Next()
+	require.True(t, ok)
+	require.Equal(t, Sort(nodes, hash)[0], first)
+}
@@ -0,0 +1,49 @@
+package hrw
+
+import "encoding/binary"
+
+// KeyBuilder builds canonical encoding of composite object keys, so keys
+// made of several fields are hashed identically by all services. Every
+// field is appended as is, no type information is stored:
+//
+//   - uint64 is encoded as 8 bytes big-endian;
+//   - strings and byte slices are encoded as their length (as uint64 above)
+//     followed by the data.
+//
+// Length prefixes make the encoding unambiguous, e.g. ("ab", "c") and
+// ("a", "bc") produce different keys. Zero value is ready to use.
+type KeyBuilder struct {
+	buf []byte
+}
+
+// AppendUint64 appends v to the key.
+func (b *KeyBuilder) AppendUint64(v uint64) *KeyBuilder {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	b.buf = append(b.buf, tmp[:]...)
+	return b
+}
+
+// AppendString appends length-prefixed s to the key.
+func (b *KeyBuilder) AppendString(s string) *KeyBuilder {
+	b.AppendUint64(uint64(len(s)))
+	b.buf = append(b.buf, s...)
+	return b
+}
+
+// AppendBytes appends length-prefixed data to the key.
+func (b *KeyBuilder) AppendBytes(data []byte) *KeyBuilder {
+	b.AppendUint64(uint64(len(data)))
+	b.buf = append(b.buf, data...)
+	return b
+}
+
+// Bytes returns the encoded key. The slice is valid until the next
+// modification of the builder.
+func (b *KeyBuilder) Bytes() []byte { return b.buf }
+
+// Hash returns hash of the encoded key, see Hash.
+func (b *KeyBuilder) Hash() uint64 { return Hash(b.buf) }
+
+// Reset clears the builder keeping allocated memory.
+func (b *KeyBuilder) Reset() { b.buf = b.buf[:0] }
@@ -0,0 +1,40 @@
+package hrw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	var b KeyBuilder
+	b.AppendString("ab").AppendUint64(0x0102).AppendBytes([]byte{0xff})
+
+	require.Equal(t, []byte{
+		0, 0, 0, 0, 0, 0, 0, 2, 'a', 'b',
+		0, 0, 0, 0, 0, 0, 1, 2,
+		0, 0, 0, 0, 0, 0, 0, 1, 0xff,
+	}, b.Bytes())
+	require.Equal(t, Hash(b.Bytes()), b.Hash())
+
+	t.Run("unambiguous", func(t *testing.T) {
+		var x, y KeyBuilder
+		x.AppendString("ab").AppendString("c")
+		y.AppendString("a").AppendString("bc")
+		require.NotEqual(t, x.Bytes(), y.Bytes())
+	})
+
+	t.Run("string and bytes", func(t *testing.T) {
+		var x, y KeyBuilder
+		x.AppendString("key")
+		y.AppendBytes([]byte("key"))
+		require.Equal(t, x.Hash(), y.Hash())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		b.Reset()
+		require.Empty(t, b.Bytes())
+		b.AppendUint64(1)
+		require.Len(t, b.Bytes(), 8)
+	})
+}
@@ -0,0 +1,67 @@
+package hrw
+
+import (
+	"sync"
+	"time"
+)
+
+// Lease keeps the leader elected for the resource until the lease
+// expires, so membership changes (e.g. a new node with a better score)
+// don't move leadership in the middle of the lease. Leadership moves
+// immediately if the leader leaves. It is safe for concurrent use.
+type Lease struct {
+	resource uint64
+	duration time.Duration
+
+	mu      sync.Mutex
+	held    bool
+	leader  uint64
+	expires time.Time
+}
+
+// ElectLeader returns index of the member coordinating the resource.
+// All parties with the same member list elect the same leader without
+// any communication. -1 is returned for an empty list.
+func ElectLeader(members []uint64, resource uint64) int {
+	if len(members) == 0 {
+		return -1
+	}
+	return closest(members, resource)
+}
+
+// NewLease returns Lease for the resource with the given duration.
+func NewLease(resource uint64, duration time.Duration) *Lease {
+	return &Lease{resource: resource, duration: duration}
+}
+
+// Leader returns index of the current leader among members at the given
+// time. A new leader is elected with ElectLeader when there is no leader
+// yet, the lease has expired or the leader is not a member anymore.
+func (l *Lease) Leader(members []uint64, now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held && now.Before(l.expires) {
+		for i := range members {
+			if members[i] == l.leader {
+				return i
+			}
+		}
+	}
+
+	i := ElectLeader(members, l.resource)
+	if i < 0 {
+		l.held = false
+		return i
+	}
+
+	l.held, l.leader, l.expires = true, members[i], now.Add(l.duration)
+	return i
+}
+
+// Expires returns the time current lease expires at.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expires
+}
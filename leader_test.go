@@ -0,0 +1,68 @@
+package hrw
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElectLeader(t *testing.T) {
+	var (
+		resource = Hash([]byte("job/cleanup"))
+		members  = make([]uint64, 5)
+	)
+	for i := range members {
+		members[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	leader := ElectLeader(members, resource)
+	require.Equal(t, Sort(members, resource)[0], uint64(leader))
+
+	// order of members doesn't matter
+	reversed := make([]uint64, len(members))
+	for i := range members {
+		reversed[len(members)-1-i] = members[i]
+	}
+	require.Equal(t, members[leader], reversed[ElectLeader(reversed, resource)])
+
+	require.Equal(t, -1, ElectLeader(nil, resource))
+}
+
+func TestLease(t *testing.T) {
+	var (
+		resource = Hash([]byte("job/cleanup"))
+		members  = make([]uint64, 5)
+		now      = time.Unix(1600000000, 0)
+	)
+	for i := range members {
+		members[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	// find a node which would take leadership from the current leader
+	var (
+		leader = ElectLeader(members, resource)
+		better uint64
+	)
+	for i := 5; ; i++ {
+		better = Hash([]byte(strconv.Itoa(i)))
+		if ElectLeader(append(members, better), resource) == len(members) {
+			break
+		}
+	}
+
+	l := NewLease(resource, time.Minute)
+	require.Equal(t, leader, l.Leader(members, now))
+	require.True(t, l.Expires().Equal(now.Add(time.Minute)))
+
+	joined := append(append([]uint64{}, members...), better)
+	require.Equal(t, leader, l.Leader(joined, now.Add(30*time.Second)))
+	require.Equal(t, len(members), l.Leader(joined, now.Add(time.Minute)))
+
+	t.Run("leader leaves", func(t *testing.T) {
+		left := append([]uint64{}, members...)
+		require.NotEqual(t, len(members), l.Leader(left, now.Add(61*time.Second)))
+		require.Equal(t, -1, l.Leader(nil, now.Add(62*time.Second)))
+	})
+}
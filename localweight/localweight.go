@@ -0,0 +1,85 @@
+// Package localweight computes the weight a node advertises for itself
+// from local signals like free disk space and load average. Every signal
+// is normalized into [0.0, 1.0] range, so weights reported by different
+// nodes are comparable and can be used for hrw weighted sorting directly.
+package localweight
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/nspcc-dev/hrw/normalizer"
+)
+
+type (
+	// Signal returns normalized value of some local resource,
+	// higher value means the node is more suitable for new data.
+	Signal func() (float64, error)
+
+	// Provider combines signals into a single weight.
+	Provider struct {
+		signals []Signal
+		norm    normalizer.FloatNorm
+	}
+)
+
+// New returns Provider multiplying values of signals and passing
+// the product through norm (e.g. normalizer.Chain of quantizer and
+// bounds), nil norm leaves the product as is.
+func New(norm normalizer.FloatNorm, signals ...Signal) *Provider {
+	return &Provider{signals: signals, norm: norm}
+}
+
+// Weight returns current weight of the node. An error is returned if
+// any of signals fails, partial weight would mislead other nodes.
+func (p *Provider) Weight() (float64, error) {
+	if len(p.signals) == 0 {
+		return 0, errors.New("no signals configured")
+	}
+
+	w := 1.0
+	for i := range p.signals {
+		v, err := p.signals[i]()
+		if err != nil {
+			return 0, err
+		}
+		w *= clamp(v)
+	}
+	if p.norm != nil {
+		w = clamp(p.norm.Normalize(w))
+	}
+	return w, nil
+}
+
+// DiskFree returns signal equal to the free fraction of the file system
+// containing path, see normalizer.CapacityU64.
+func DiskFree(path string) Signal {
+	return func() (float64, error) {
+		free, total, err := diskUsage(path)
+		if err != nil {
+			return 0, fmt.Errorf("can't get disk usage of %s: %w", path, err)
+		}
+		return normalizer.NewCapacityU64().Normalize(total-free, total), nil
+	}
+}
+
+// LoadAverage returns signal equal to 1 - load / CPUs, where load is
+// the 5-minute load average. Loads exceeding the number of CPUs yield 0.
+func LoadAverage() Signal {
+	return func() (float64, error) {
+		load, err := loadAverage()
+		if err != nil {
+			return 0, fmt.Errorf("can't get load average: %w", err)
+		}
+		return 1 - normalizer.NewMaxF64(float64(runtime.NumCPU())).Normalize(load), nil
+	}
+}
+
+func clamp(w float64) float64 {
+	if math.IsNaN(w) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, w))
+}
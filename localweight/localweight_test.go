@@ -0,0 +1,47 @@
+package localweight
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nspcc-dev/hrw/normalizer"
+	"github.com/stretchr/testify/require"
+)
+
+func constant(v float64) Signal {
+	return func() (float64, error) { return v, nil }
+}
+
+func TestProvider(t *testing.T) {
+	w, err := New(nil, constant(0.5), constant(0.5)).Weight()
+	require.NoError(t, err)
+	require.Equal(t, 0.25, w)
+
+	t.Run("normalizer", func(t *testing.T) {
+		q, err := normalizer.NewQuantizeF64(2)
+		require.NoError(t, err)
+
+		w, err := New(q, constant(0.8)).Weight()
+		require.NoError(t, err)
+		require.Equal(t, 1.0, w)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		w, err := New(nil, constant(2), constant(0.5)).Weight()
+		require.NoError(t, err)
+		require.Equal(t, 0.5, w)
+
+		w, err = New(nil, constant(-1)).Weight()
+		require.NoError(t, err)
+		require.Equal(t, 0.0, w)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		failing := func() (float64, error) { return 0, errors.New("failed") }
+		_, err := New(nil, constant(1), failing).Weight()
+		require.Error(t, err)
+
+		_, err = New(nil).Weight()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,41 @@
+//go:build linux
+// +build linux
+
+package localweight
+
+import (
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const loadavgPath = "/proc/loadavg"
+
+func diskUsage(path string) (free, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	// blocks reserved for root are not available for the node
+	return st.Bavail * bsize, (st.Blocks - st.Bfree + st.Bavail) * bsize, nil
+}
+
+func loadAverage() (float64, error) {
+	data, err := ioutil.ReadFile(loadavgPath)
+	if err != nil {
+		return 0, err
+	}
+	return parseLoadavg(string(data))
+}
+
+// parseLoadavg returns the 5-minute load average from /proc/loadavg contents.
+func parseLoadavg(s string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return 0, errors.New("invalid loadavg format")
+	}
+	return strconv.ParseFloat(fields[1], 64)
+}
@@ -0,0 +1,36 @@
+//go:build linux
+// +build linux
+
+package localweight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskFree(t *testing.T) {
+	w, err := DiskFree(".")()
+	require.NoError(t, err)
+	require.True(t, w >= 0 && w <= 1, "weight %f", w)
+
+	_, err = DiskFree("/nonexistent/path")()
+	require.Error(t, err)
+}
+
+func TestLoadAverage(t *testing.T) {
+	w, err := LoadAverage()()
+	require.NoError(t, err)
+	require.True(t, w >= 0 && w <= 1, "weight %f", w)
+}
+
+func TestParseLoadavg(t *testing.T) {
+	load, err := parseLoadavg("0.52 1.58 0.59 1/389 12345\n")
+	require.NoError(t, err)
+	require.Equal(t, 1.58, load)
+
+	_, err = parseLoadavg("")
+	require.Error(t, err)
+	_, err = parseLoadavg("0.5 x")
+	require.Error(t, err)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package localweight
+
+import "errors"
+
+var errUnsupported = errors.New("not supported on this platform")
+
+func diskUsage(string) (uint64, uint64, error) { return 0, 0, errUnsupported }
+
+func loadAverage() (float64, error) { return 0, errUnsupported }
@@ -0,0 +1,31 @@
+package hrw
+
+import "sync"
+
+// memoHasher computes hash of the wrapped Hasher once.
+type memoHasher struct {
+	h    Hasher
+	once sync.Once
+	hash uint64
+}
+
+// Memoize returns Hasher which calls h.Hash on the first use only and
+// returns the cached value afterwards. It is meant for expensive Hash
+// implementations (e.g. ones encoding the whole node structure) and is
+// safe for concurrent use.
+func Memoize(h Hasher) Hasher {
+	return &memoHasher{h: h}
+}
+
+// Hash implements Hasher interface.
+func (m *memoHasher) Hash() uint64 {
+	m.once.Do(func() { m.hash = m.h.Hash() })
+	return m.hash
+}
+
+// SliceHashes returns hashes of []T elements as SortSliceByValue computes
+// them, or nil if the slice is not supported. They can be kept while the
+// slice doesn't change and passed to SortSliceByHash on every sort.
+func SliceHashes(slice interface{}) []uint64 {
+	return prepareRule(slice)
+}
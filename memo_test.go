@@ -0,0 +1,69 @@
+package hrw
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingHasher struct {
+	mu    sync.Mutex
+	calls int
+	hash  uint64
+}
+
+func (c *countingHasher) Hash() uint64 {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return c.hash
+}
+
+func TestMemoize(t *testing.T) {
+	var (
+		c  = &countingHasher{hash: 42}
+		m  = Memoize(c)
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = m.Hash()
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint64(42), m.Hash())
+	require.Equal(t, 1, c.calls)
+
+	var (
+		hash   = Hash(testKey)
+		nodes  = []hashString{"a", "b", "c", "d", "e", "f"}
+		memo   = make([]Hasher, len(nodes))
+		expect = SortedSliceByValue(nodes, hash).([]hashString)
+	)
+	for i := range nodes {
+		memo[i] = Memoize(nodes[i])
+	}
+	SortSliceByValue(memo, hash)
+	for i := range memo {
+		require.Equal(t, expect[i].Hash(), memo[i].Hash())
+	}
+}
+
+func TestSliceHashes(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = []string{"a", "b", "c", "d", "e", "f"}
+	)
+
+	hashes := SliceHashes(nodes)
+	require.Len(t, hashes, len(nodes))
+	require.Equal(t, Hash([]byte("a")), hashes[0])
+
+	SortSliceByHash(nodes, hashes, hash)
+	require.Equal(t, []string{"d", "f", "c", "b", "a", "e"}, nodes)
+
+	require.Nil(t, SliceHashes([]unknown{1}))
+}
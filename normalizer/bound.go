@@ -0,0 +1,34 @@
+package normalizer
+
+import (
+	"errors"
+	"math"
+)
+
+type bound struct {
+	n           FloatNorm
+	floor, ceil float64
+}
+
+// Bound returns normalizer clamping weights produced by n to [floor, ceil],
+// so every node keeps at least a minimal share and no node gets more than
+// the maximal one regardless of raw metrics. NaN weights are replaced by
+// floor. 0 <= floor <= ceil <= 1 must hold.
+func Bound(n FloatNorm, floor, ceil float64) (FloatNorm, error) {
+	if math.IsNaN(floor) || math.IsNaN(ceil) || floor < 0 || ceil > 1 || floor > ceil {
+		return nil, errors.New("bounds must satisfy 0 <= floor <= ceil <= 1")
+	}
+	return bound{n: n, floor: floor, ceil: ceil}, nil
+}
+
+// Normalize implements FloatNorm interface.
+func (b bound) Normalize(w float64) float64 {
+	w = b.n.Normalize(w)
+	switch {
+	case math.IsNaN(w) || w < b.floor:
+		return b.floor
+	case w > b.ceil:
+		return b.ceil
+	}
+	return w
+}
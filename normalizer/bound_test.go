@@ -0,0 +1,32 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBound(t *testing.T) {
+	n, err := Bound(NewMaxF64(100), 0.1, 0.8)
+	require.NoError(t, err)
+
+	require.Equal(t, 0.1, n.Normalize(0))
+	require.Equal(t, 0.1, n.Normalize(5))
+	require.Equal(t, 0.5, n.Normalize(50))
+	require.Equal(t, 0.8, n.Normalize(90))
+	require.Equal(t, 0.8, n.Normalize(math.Inf(1)))
+
+	t.Run("NaN", func(t *testing.T) {
+		n, err := Bound(Chain(), 0.2, 1)
+		require.NoError(t, err)
+		require.Equal(t, 0.2, n.Normalize(math.NaN()))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, b := range [][2]float64{{-0.1, 1}, {0, 1.1}, {0.6, 0.5}, {math.NaN(), 1}, {0, math.NaN()}} {
+			_, err := Bound(NewMaxF64(1), b[0], b[1])
+			require.Error(t, err, "floor %f, ceil %f", b[0], b[1])
+		}
+	})
+}
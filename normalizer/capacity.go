@@ -0,0 +1,54 @@
+// Package normalizer converts raw node metrics into weights
+// suitable for hrw weighted sorting, i.e. values between
+// hrw.NormalizedMinWeight and hrw.NormalizedMaxWeight.
+package normalizer
+
+import (
+	"math"
+
+	"github.com/nspcc-dev/hrw/fixed64"
+)
+
+type (
+	// CapacityF64 converts (used, total) float64 counters into
+	// the free fraction of the capacity.
+	CapacityF64 struct{}
+
+	// CapacityU64 converts (used, total) uint64 counters into
+	// the free fraction of the capacity.
+	CapacityU64 struct{}
+)
+
+// NewCapacityF64 returns capacity normalizer for float64 counters.
+func NewCapacityF64() CapacityF64 { return CapacityF64{} }
+
+// NewCapacityU64 returns capacity normalizer for uint64 counters.
+func NewCapacityU64() CapacityU64 { return CapacityU64{} }
+
+// Normalize returns (total - used) / total. Zero, negative, infinite
+// or NaN total yields 0, as well as used exceeding total. Negative used
+// is treated as 0.
+func (CapacityF64) Normalize(used, total float64) float64 {
+	if math.IsNaN(used) || math.IsNaN(total) || math.IsInf(total, 0) || total <= 0 || used >= total {
+		return 0
+	}
+	if used <= 0 {
+		return 1
+	}
+	return (total - used) / total
+}
+
+// Normalize returns (total - used) / total. Zero total yields 0,
+// as well as used exceeding total. The ratio is calculated with
+// 128-bit integer division, so huge counters don't lose precision
+// before the final conversion to float64.
+func (CapacityU64) Normalize(used, total uint64) float64 {
+	if total == 0 || used >= total {
+		return 0
+	}
+	if used == 0 {
+		return 1
+	}
+	// free < total, so the ratio doesn't saturate
+	return fixed64.Float64(fixed64.Ratio(total-used, total))
+}
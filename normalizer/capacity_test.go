@@ -0,0 +1,49 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityF64(t *testing.T) {
+	n := NewCapacityF64()
+
+	require.Equal(t, 1.0, n.Normalize(0, 100))
+	require.Equal(t, 0.75, n.Normalize(25, 100))
+	require.Equal(t, 0.0, n.Normalize(100, 100))
+
+	t.Run("guards", func(t *testing.T) {
+		require.Equal(t, 0.0, n.Normalize(0, 0))
+		require.Equal(t, 0.0, n.Normalize(10, 0))
+		require.Equal(t, 0.0, n.Normalize(0, -1))
+		require.Equal(t, 0.0, n.Normalize(200, 100))
+		require.Equal(t, 1.0, n.Normalize(-5, 100))
+		require.Equal(t, 0.0, n.Normalize(math.NaN(), 100))
+		require.Equal(t, 0.0, n.Normalize(0, math.NaN()))
+		require.Equal(t, 0.0, n.Normalize(5, math.Inf(1)))
+		require.Equal(t, 0.0, n.Normalize(5, math.Inf(-1)))
+	})
+}
+
+func TestCapacityU64(t *testing.T) {
+	n := NewCapacityU64()
+
+	require.Equal(t, 1.0, n.Normalize(0, 100))
+	require.Equal(t, 0.75, n.Normalize(25, 100))
+	require.Equal(t, 0.0, n.Normalize(100, 100))
+
+	t.Run("guards", func(t *testing.T) {
+		require.Equal(t, 0.0, n.Normalize(0, 0))
+		require.Equal(t, 0.0, n.Normalize(10, 0))
+		require.Equal(t, 0.0, n.Normalize(200, 100))
+	})
+
+	t.Run("huge counters", func(t *testing.T) {
+		const total = math.MaxUint64
+		require.Equal(t, 0.5, n.Normalize(total/2+1, total))
+		w := n.Normalize(total-1, total)
+		require.True(t, w > 0 && w < 1e-18)
+	})
+}
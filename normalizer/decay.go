@@ -0,0 +1,62 @@
+package normalizer
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Decay keeps the last reported weight of a node and decays it towards
+// the floor as the report gets older, so nodes which stop reporting
+// metrics don't keep their last good weight forever. It is safe
+// for concurrent use.
+type Decay struct {
+	halfLife time.Duration
+	floor    float64
+
+	mu      sync.Mutex
+	weight  float64
+	updated time.Time
+}
+
+// NewDecay returns Decay normalizer which halves the distance between
+// the reported weight and the floor every halfLife. Weight of the node
+// which has never reported is equal to the floor.
+func NewDecay(halfLife time.Duration, floor float64) (*Decay, error) {
+	if halfLife <= 0 {
+		return nil, errors.New("half-life must be positive")
+	} else if math.IsNaN(floor) || floor < 0 || floor > 1 {
+		return nil, errors.New("floor must be between 0.0 and 1.0")
+	}
+	return &Decay{halfLife: halfLife, floor: floor, weight: floor}, nil
+}
+
+// Update stores normalized weight w reported at the given time.
+// Reports older than the stored one and NaN weights are ignored,
+// weights outside of [0.0, 1.0] are clamped.
+func (d *Decay) Update(w float64, at time.Time) {
+	if math.IsNaN(w) {
+		return
+	}
+	w = math.Max(0, math.Min(1, w))
+
+	d.mu.Lock()
+	if !at.Before(d.updated) {
+		d.weight, d.updated = w, at
+	}
+	d.mu.Unlock()
+}
+
+// Weight returns the decayed weight at the given time.
+func (d *Decay) Weight(now time.Time) float64 {
+	d.mu.Lock()
+	w, updated := d.weight, d.updated
+	d.mu.Unlock()
+
+	age := now.Sub(updated)
+	if updated.IsZero() || age <= 0 {
+		return w
+	}
+	return d.floor + (w-d.floor)*math.Exp2(-float64(age)/float64(d.halfLife))
+}
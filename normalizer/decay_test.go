@@ -0,0 +1,68 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDecay(t *testing.T) {
+	_, err := NewDecay(0, 0.1)
+	require.Error(t, err)
+	_, err = NewDecay(-time.Second, 0.1)
+	require.Error(t, err)
+	_, err = NewDecay(time.Second, -0.1)
+	require.Error(t, err)
+	_, err = NewDecay(time.Second, 1.1)
+	require.Error(t, err)
+	_, err = NewDecay(time.Second, math.NaN())
+	require.Error(t, err)
+
+	d, err := NewDecay(time.Second, 0.1)
+	require.NoError(t, err)
+	require.Equal(t, 0.1, d.Weight(time.Now()))
+}
+
+func TestDecay(t *testing.T) {
+	var (
+		now   = time.Unix(1600000000, 0)
+		d, _  = NewDecay(time.Minute, 0.2)
+		delta = 1e-12
+	)
+
+	d.Update(1, now)
+	require.Equal(t, 1.0, d.Weight(now))
+	require.Equal(t, 1.0, d.Weight(now.Add(-time.Second)))
+	require.InDelta(t, 0.6, d.Weight(now.Add(time.Minute)), delta)
+	require.InDelta(t, 0.4, d.Weight(now.Add(2*time.Minute)), delta)
+	require.InDelta(t, 0.2, d.Weight(now.Add(time.Hour)), 1e-9)
+
+	t.Run("stale update", func(t *testing.T) {
+		d.Update(0.5, now.Add(-time.Minute))
+		require.Equal(t, 1.0, d.Weight(now))
+	})
+
+	t.Run("fresh update", func(t *testing.T) {
+		d.Update(0.5, now.Add(time.Minute))
+		require.Equal(t, 0.5, d.Weight(now.Add(time.Minute)))
+		require.InDelta(t, 0.35, d.Weight(now.Add(2*time.Minute)), delta)
+	})
+
+	t.Run("below floor", func(t *testing.T) {
+		d.Update(0, now.Add(2*time.Minute))
+		require.InDelta(t, 0.1, d.Weight(now.Add(3*time.Minute)), delta)
+	})
+
+	t.Run("invalid weight", func(t *testing.T) {
+		at := now.Add(4 * time.Minute)
+		d.Update(math.NaN(), at)
+		require.False(t, math.IsNaN(d.Weight(at)))
+
+		d.Update(2, at)
+		require.Equal(t, 1.0, d.Weight(at))
+		d.Update(math.Inf(-1), at)
+		require.Equal(t, 0.0, d.Weight(at))
+	})
+}
@@ -0,0 +1,154 @@
+package normalizer
+
+import (
+	"math"
+
+	"github.com/nspcc-dev/hrw/fixed64"
+)
+
+type (
+	// FloatNorm normalizes float64 metric value into weight.
+	FloatNorm interface{ Normalize(w float64) float64 }
+
+	// Uint64Norm normalizes uint64 metric value into weight.
+	Uint64Norm interface{ Normalize(w uint64) float64 }
+
+	maxF64 struct{ max float64 }
+
+	minMaxF64 struct{ min, max float64 }
+
+	maxU64 struct{ max uint64 }
+
+	chain []FloatNorm
+)
+
+// NewMaxF64 returns normalizer dividing values by max.
+// Values outside of [0, max] are clamped.
+func NewMaxF64(max float64) FloatNorm { return maxF64{max: max} }
+
+// NewMinMaxF64 returns normalizer mapping [min, max] onto [0, 1].
+// Values outside of [min, max] are clamped. If min equals max,
+// every value not less than min is normalized to 1.
+func NewMinMaxF64(min, max float64) FloatNorm { return minMaxF64{min: min, max: max} }
+
+// NewMaxU64 returns normalizer dividing values by max.
+// Values greater than max are clamped.
+func NewMaxU64(max uint64) Uint64Norm { return maxU64{max: max} }
+
+// Chain returns normalizer applying ns one after another, e.g. converting
+// raw metric into weight and then quantizing it. Empty chain returns
+// values as is.
+func Chain(ns ...FloatNorm) FloatNorm { return chain(ns) }
+
+// AutoMax returns NewMaxF64 normalizer with the maximum
+// of finite values from ws.
+func AutoMax(ws []float64) FloatNorm {
+	_, max := bounds(ws)
+	return NewMaxF64(max)
+}
+
+// AutoMinMax returns NewMinMaxF64 normalizer with the minimum
+// and the maximum of finite values from ws. Note, that the node
+// with the minimal value gets zero weight.
+func AutoMinMax(ws []float64) FloatNorm {
+	min, max := bounds(ws)
+	return NewMinMaxF64(min, max)
+}
+
+// AutoMaxU64 returns NewMaxU64 normalizer with the maximum from ws.
+func AutoMaxU64(ws []uint64) Uint64Norm {
+	var max uint64
+	for i := range ws {
+		if ws[i] > max {
+			max = ws[i]
+		}
+	}
+	return NewMaxU64(max)
+}
+
+func bounds(ws []float64) (min, max float64) {
+	first := true
+	for i := range ws {
+		if math.IsNaN(ws[i]) || math.IsInf(ws[i], 0) {
+			continue
+		}
+		if first || ws[i] < min {
+			min = ws[i]
+		}
+		if first || ws[i] > max {
+			max = ws[i]
+		}
+		first = false
+	}
+	return
+}
+
+// Normalize implements FloatNorm interface.
+func (n maxF64) Normalize(w float64) float64 {
+	if math.IsNaN(w) || w <= 0 || n.max <= 0 {
+		return 0
+	} else if w >= n.max {
+		return 1
+	}
+	return w / n.max
+}
+
+// Normalize implements FloatNorm interface.
+func (n minMaxF64) Normalize(w float64) float64 {
+	switch {
+	case math.IsNaN(w) || w < n.min:
+		return 0
+	case w >= n.max:
+		return 1
+	}
+	return (w - n.min) / (n.max - n.min)
+}
+
+// Normalize implements FloatNorm interface.
+func (c chain) Normalize(w float64) float64 {
+	for i := range c {
+		w = c[i].Normalize(w)
+	}
+	return w
+}
+
+// Normalize implements Uint64Norm interface.
+func (n maxU64) Normalize(w uint64) float64 {
+	if w == 0 || n.max == 0 {
+		return 0
+	} else if w >= n.max {
+		return 1
+	}
+	return fixed64.Float64(fixed64.Ratio(w, n.max))
+}
+
+// Apply returns new slice with every value from ws normalized by n.
+func Apply(n FloatNorm, ws []float64) []float64 {
+	return ApplyTo(make([]float64, len(ws)), n, ws)
+}
+
+// ApplyTo writes values from ws normalized by n into dst and returns it.
+// dst must be at least as long as ws, ws itself can be used as dst
+// to normalize in place.
+func ApplyTo(dst []float64, n FloatNorm, ws []float64) []float64 {
+	dst = dst[:len(ws)]
+	for i := range ws {
+		dst[i] = n.Normalize(ws[i])
+	}
+	return dst
+}
+
+// ApplyU64 returns new slice with every value from ws normalized by n.
+func ApplyU64(n Uint64Norm, ws []uint64) []float64 {
+	return ApplyU64To(make([]float64, len(ws)), n, ws)
+}
+
+// ApplyU64To writes values from ws normalized by n into dst and returns it.
+// dst must be at least as long as ws.
+func ApplyU64To(dst []float64, n Uint64Norm, ws []uint64) []float64 {
+	dst = dst[:len(ws)]
+	for i := range ws {
+		dst[i] = n.Normalize(ws[i])
+	}
+	return dst
+}
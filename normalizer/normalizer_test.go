@@ -0,0 +1,98 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoMax(t *testing.T) {
+	ws := []float64{10, 20, 40, math.NaN(), math.Inf(1)}
+	n := AutoMax(ws)
+
+	require.Equal(t, 0.25, n.Normalize(10))
+	require.Equal(t, 0.5, n.Normalize(20))
+	require.Equal(t, 1.0, n.Normalize(40))
+	require.Equal(t, 1.0, n.Normalize(80))
+	require.Equal(t, 0.0, n.Normalize(-1))
+	require.Equal(t, 0.0, n.Normalize(math.NaN()))
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, 0.0, AutoMax(nil).Normalize(10))
+	})
+}
+
+func TestChain(t *testing.T) {
+	q, err := NewQuantizeF64(4)
+	require.NoError(t, err)
+
+	n := Chain(NewMaxF64(100), q)
+	require.Equal(t, 0.25, n.Normalize(20))
+	require.Equal(t, 0.5, n.Normalize(45))
+	require.Equal(t, 1.0, n.Normalize(150))
+
+	require.Equal(t, 42.0, Chain().Normalize(42))
+}
+
+func TestAutoMinMax(t *testing.T) {
+	n := AutoMinMax([]float64{10, 20, 30})
+
+	require.Equal(t, 0.0, n.Normalize(10))
+	require.Equal(t, 0.5, n.Normalize(20))
+	require.Equal(t, 1.0, n.Normalize(30))
+	require.Equal(t, 0.0, n.Normalize(5))
+	require.Equal(t, 1.0, n.Normalize(35))
+
+	t.Run("same values", func(t *testing.T) {
+		n := AutoMinMax([]float64{7, 7})
+		require.Equal(t, 1.0, n.Normalize(7))
+		require.Equal(t, 0.0, n.Normalize(6))
+	})
+}
+
+func TestAutoMaxU64(t *testing.T) {
+	n := AutoMaxU64([]uint64{1 << 20, 1 << 40, 1 << 62})
+
+	require.Equal(t, 0.25, n.Normalize(1<<60))
+	require.Equal(t, 1.0, n.Normalize(1<<62))
+	require.Equal(t, 1.0, n.Normalize(math.MaxUint64))
+	require.Equal(t, 0.0, n.Normalize(0))
+	require.Equal(t, 0.0, AutoMaxU64(nil).Normalize(10))
+}
+
+func TestApply(t *testing.T) {
+	n := NewMaxF64(40)
+
+	t.Run("new slice", func(t *testing.T) {
+		ws := []float64{10, 20, 40}
+		require.Equal(t, []float64{0.25, 0.5, 1}, Apply(n, ws))
+		require.Equal(t, []float64{10, 20, 40}, ws)
+	})
+
+	t.Run("in place", func(t *testing.T) {
+		ws := []float64{10, 20, 40}
+		res := ApplyTo(ws, n, ws)
+		require.Equal(t, []float64{0.25, 0.5, 1}, ws)
+		require.Equal(t, ws, res)
+	})
+
+	t.Run("destination", func(t *testing.T) {
+		dst := make([]float64, 5)
+		require.Equal(t, []float64{0.25, 0.5}, ApplyTo(dst, n, []float64{10, 20}))
+		require.Panics(t, func() { ApplyTo(make([]float64, 1), n, []float64{10, 20}) })
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Empty(t, Apply(n, nil))
+	})
+}
+
+func TestApplyU64(t *testing.T) {
+	n := NewMaxU64(40)
+
+	require.Equal(t, []float64{0.25, 0.5, 1}, ApplyU64(n, []uint64{10, 20, 40}))
+
+	dst := make([]float64, 3)
+	require.Equal(t, []float64{0.25, 0.5}, ApplyU64To(dst, n, []uint64{10, 20}))
+}
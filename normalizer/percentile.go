@@ -0,0 +1,147 @@
+package normalizer
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Percentile normalizes values by their rank among the observed ones.
+// The distribution is approximated with the extended P² algorithm
+// (Jain & Chlamtac; Raatikainen) using a fixed number of markers,
+// so samples are not stored. Ranks are robust to heavy-tailed metrics
+// where a single outlier would squash all weights produced by
+// max-based normalizers. It is safe for concurrent use.
+type Percentile struct {
+	mu sync.Mutex
+	// b is the number of cells, there are b+1 markers.
+	b     int
+	count int
+	// q contains marker heights, n contains their actual positions.
+	q []float64
+	n []float64
+}
+
+// NewPercentile returns Percentile normalizer tracking cells equally spaced
+// quantiles of the distribution. More cells give more precise ranks.
+func NewPercentile(cells int) (*Percentile, error) {
+	if cells < 2 {
+		return nil, errors.New("number of cells must be at least 2")
+	}
+	return &Percentile{
+		b: cells,
+		q: make([]float64, 0, cells+1),
+		n: make([]float64, cells+1),
+	}, nil
+}
+
+// Normalize implements FloatNorm interface. The value is observed
+// and its rank is returned, see Observe and Rank.
+func (p *Percentile) Normalize(x float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.observe(x)
+	return p.rank(x)
+}
+
+// Observe adds the value to the distribution. NaN and infinite values
+// are ignored.
+func (p *Percentile) Observe(x float64) {
+	p.mu.Lock()
+	p.observe(x)
+	p.mu.Unlock()
+}
+
+// Rank returns estimated fraction of observed values not greater than x.
+// Rank is 0 if nothing is observed yet.
+func (p *Percentile) Rank(x float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rank(x)
+}
+
+// Count returns number of observed values.
+func (p *Percentile) Count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count
+}
+
+func (p *Percentile) observe(x float64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return
+	}
+
+	p.count++
+	if len(p.q) <= p.b {
+		// initial observations are stored exactly
+		i := sort.SearchFloat64s(p.q, x)
+		p.q = append(p.q, 0)
+		copy(p.q[i+1:], p.q[i:])
+		p.q[i] = x
+		for i := range p.q {
+			p.n[i] = float64(i + 1)
+		}
+		return
+	}
+
+	var k int
+	switch {
+	case x < p.q[0]:
+		p.q[0] = x
+	case x >= p.q[p.b]:
+		p.q[p.b] = x
+		k = p.b - 1
+	default:
+		for k = 0; x >= p.q[k+1]; k++ {
+		}
+	}
+	for i := k + 1; i <= p.b; i++ {
+		p.n[i]++
+	}
+
+	for i := 1; i < p.b; i++ {
+		want := 1 + float64(i)*float64(p.count-1)/float64(p.b)
+		d := want - p.n[i]
+		if (d >= 1 && p.n[i+1]-p.n[i] > 1) || (d <= -1 && p.n[i-1]-p.n[i] < -1) {
+			d = math.Copysign(1, d)
+			q := p.parabolic(i, d)
+			if q <= p.q[i-1] || q >= p.q[i+1] {
+				q = p.linear(i, int(d))
+			}
+			p.q[i] = q
+			p.n[i] += d
+		}
+	}
+}
+
+func (p *Percentile) parabolic(i int, d float64) float64 {
+	q, n := p.q, p.n
+	return q[i] + d/(n[i+1]-n[i-1])*
+		((n[i]-n[i-1]+d)*(q[i+1]-q[i])/(n[i+1]-n[i])+
+			(n[i+1]-n[i]-d)*(q[i]-q[i-1])/(n[i]-n[i-1]))
+}
+
+func (p *Percentile) linear(i, d int) float64 {
+	return p.q[i] + float64(d)*(p.q[i+d]-p.q[i])/(p.n[i+d]-p.n[i])
+}
+
+func (p *Percentile) rank(x float64) float64 {
+	l := len(p.q)
+	switch {
+	case l == 0 || math.IsNaN(x) || x < p.q[0]:
+		return 0
+	case x >= p.q[l-1]:
+		return 1
+	}
+
+	// markers are interpolated linearly by their positions
+	i := sort.Search(l, func(i int) bool { return p.q[i] > x }) - 1
+	pos := p.n[i]
+	if p.q[i+1] > p.q[i] {
+		pos += (x - p.q[i]) / (p.q[i+1] - p.q[i]) * (p.n[i+1] - p.n[i])
+	}
+	return pos / float64(p.count)
+}
@@ -0,0 +1,85 @@
+package normalizer
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPercentile(t *testing.T) {
+	_, err := NewPercentile(1)
+	require.Error(t, err)
+
+	p, err := NewPercentile(10)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, p.Rank(5))
+}
+
+func TestPercentile(t *testing.T) {
+	t.Run("few values", func(t *testing.T) {
+		p, _ := NewPercentile(10)
+		for _, x := range []float64{4, 1, 3, 2} {
+			p.Observe(x)
+		}
+		require.Equal(t, 4, p.Count())
+		require.Equal(t, 0.0, p.Rank(0))
+		require.Equal(t, 0.25, p.Rank(1))
+		require.Equal(t, 0.5, p.Rank(2))
+		require.Equal(t, 1.0, p.Rank(4))
+		require.Equal(t, 1.0, p.Rank(100))
+	})
+
+	t.Run("infinite values", func(t *testing.T) {
+		p, _ := NewPercentile(4)
+		expected, _ := NewPercentile(4)
+		p.Observe(math.Inf(1))
+		p.Observe(math.Inf(-1))
+		for i := 0; i < 10; i++ {
+			p.Observe(float64(i))
+			expected.Observe(float64(i))
+		}
+		require.Equal(t, 10, p.Count())
+		require.Equal(t, expected.Rank(5), p.Rank(5))
+		require.True(t, p.Rank(5) < p.Rank(9))
+	})
+
+	t.Run("uniform", func(t *testing.T) {
+		var (
+			p, _ = NewPercentile(20)
+			r    = rand.New(rand.NewSource(1))
+		)
+		for i := 0; i < 100000; i++ {
+			p.Observe(r.Float64() * 1000)
+		}
+		for _, x := range []float64{100, 250, 500, 900} {
+			require.InDelta(t, x/1000, p.Rank(x), 0.01)
+		}
+	})
+
+	t.Run("heavy tail", func(t *testing.T) {
+		var (
+			p, _ = NewPercentile(20)
+			r    = rand.New(rand.NewSource(1))
+		)
+		for i := 0; i < 100000; i++ {
+			// Pareto distribution with alpha = 1
+			p.Observe(1 / (1 - r.Float64()))
+		}
+		p.Observe(1e12)
+
+		// CDF is 1 - 1/x
+		for _, x := range []float64{1.25, 2, 5, 10} {
+			require.InDelta(t, 1-1/x, p.Rank(x), 0.02)
+		}
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		p, _ := NewPercentile(10)
+		require.Equal(t, 1.0, p.Normalize(10))
+		require.Equal(t, 0.5, p.Normalize(5))
+		require.Equal(t, 0.0, p.Normalize(math.NaN()))
+		require.Equal(t, 2, p.Count())
+	})
+}
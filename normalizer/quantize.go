@@ -0,0 +1,36 @@
+package normalizer
+
+import (
+	"errors"
+	"math"
+)
+
+type quantizeF64 struct{ buckets float64 }
+
+// NewQuantizeF64 returns normalizer snapping normalized weights to
+// one of the buckets levels 1/buckets, 2/buckets, ..., 1. Weights are
+// rounded to the nearest level, positive weights are never snapped
+// to zero and zero weight stays zero. Coarse weight classes reduce
+// ordering churn caused by metric noise.
+func NewQuantizeF64(buckets int) (FloatNorm, error) {
+	if buckets < 1 {
+		return nil, errors.New("number of buckets must be positive")
+	}
+	return quantizeF64{buckets: float64(buckets)}, nil
+}
+
+// Normalize implements FloatNorm interface.
+func (n quantizeF64) Normalize(w float64) float64 {
+	switch {
+	case math.IsNaN(w) || w <= 0:
+		return 0
+	case w >= 1:
+		return 1
+	}
+
+	k := math.Round(w * n.buckets)
+	if k == 0 {
+		k = 1
+	}
+	return k / n.buckets
+}
@@ -0,0 +1,50 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantizeF64(t *testing.T) {
+	_, err := NewQuantizeF64(0)
+	require.Error(t, err)
+
+	n, err := NewQuantizeF64(4)
+	require.NoError(t, err)
+
+	cases := map[float64]float64{
+		0:     0,
+		0.01:  0.25,
+		0.2:   0.25,
+		0.3:   0.25,
+		0.4:   0.5,
+		0.5:   0.5,
+		0.74:  0.75,
+		0.9:   1,
+		1:     1,
+		2:     1,
+		-1:    0,
+		0.125: 0.25,
+	}
+	for w, expected := range cases {
+		require.Equal(t, expected, n.Normalize(w), "weight %v", w)
+	}
+	require.Equal(t, 0.0, n.Normalize(math.NaN()))
+
+	t.Run("single bucket", func(t *testing.T) {
+		n, err := NewQuantizeF64(1)
+		require.NoError(t, err)
+		require.Equal(t, 1.0, n.Normalize(0.1))
+		require.Equal(t, 0.0, n.Normalize(0))
+	})
+
+	t.Run("exact levels", func(t *testing.T) {
+		n, _ := NewQuantizeF64(10)
+		for i := 1; i <= 10; i++ {
+			w := float64(i) / 10
+			require.Equal(t, w, n.Normalize(w))
+		}
+	})
+}
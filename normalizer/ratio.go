@@ -0,0 +1,47 @@
+package normalizer
+
+import "math"
+
+type (
+	// RatioF64 converts (successes, attempts) float64 counters into
+	// the smoothed success ratio.
+	RatioF64 struct{}
+
+	// RatioU64 converts (successes, attempts) uint64 counters into
+	// the smoothed success ratio.
+	RatioU64 struct{}
+)
+
+// NewRatioF64 returns success ratio normalizer for float64 counters.
+func NewRatioF64() RatioF64 { return RatioF64{} }
+
+// NewRatioU64 returns success ratio normalizer for uint64 counters.
+func NewRatioU64() RatioU64 { return RatioU64{} }
+
+// Normalize returns (successes + 1) / (attempts + 2), i.e. the success
+// ratio with Laplace smoothing: node without attempts gets 0.5 and a few
+// failures don't zero the weight of a fresh node. Successes exceeding
+// attempts are clamped. NaN, infinite and negative counters are treated
+// as 0.
+func (RatioF64) Normalize(successes, attempts float64) float64 {
+	successes, attempts = counter(successes), counter(attempts)
+	if successes > attempts {
+		successes = attempts
+	}
+	return (successes + 1) / (attempts + 2)
+}
+
+// Normalize returns (successes + 1) / (attempts + 2), see RatioF64.
+func (RatioU64) Normalize(successes, attempts uint64) float64 {
+	if successes > attempts {
+		successes = attempts
+	}
+	return (float64(successes) + 1) / (float64(attempts) + 2)
+}
+
+func counter(c float64) float64 {
+	if math.IsNaN(c) || math.IsInf(c, 0) || c < 0 {
+		return 0
+	}
+	return c
+}
@@ -0,0 +1,36 @@
+package normalizer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatioF64(t *testing.T) {
+	n := NewRatioF64()
+
+	require.Equal(t, 0.5, n.Normalize(0, 0))
+	require.Equal(t, 0.75, n.Normalize(2, 2))
+	require.Equal(t, 0.25, n.Normalize(0, 2))
+	require.InDelta(t, 0.99, n.Normalize(9899, 9998), 1e-12)
+
+	t.Run("guards", func(t *testing.T) {
+		require.Equal(t, 0.75, n.Normalize(5, 2))
+		require.Equal(t, 0.5, n.Normalize(math.NaN(), math.NaN()))
+		require.Equal(t, 0.25, n.Normalize(-1, 2))
+		require.Equal(t, 0.5, n.Normalize(1, math.Inf(1)))
+	})
+}
+
+func TestRatioU64(t *testing.T) {
+	n := NewRatioU64()
+
+	require.Equal(t, 0.5, n.Normalize(0, 0))
+	require.Equal(t, 0.75, n.Normalize(2, 2))
+	require.Equal(t, 0.25, n.Normalize(0, 2))
+	require.Equal(t, 0.75, n.Normalize(5, 2))
+
+	w := n.Normalize(math.MaxUint64, math.MaxUint64)
+	require.True(t, w > 0.99 && w <= 1)
+}
@@ -0,0 +1,75 @@
+package normalizer
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/nspcc-dev/hrw/fixed64"
+)
+
+type (
+	// RunningMaxF64 normalizes values by the maximum value it has ever
+	// normalized. It is safe for concurrent use.
+	RunningMaxF64 struct{ max uint64 }
+
+	// RunningMaxU64 normalizes values by the maximum value it has ever
+	// normalized. It is safe for concurrent use.
+	RunningMaxU64 struct{ max uint64 }
+)
+
+// NewRunningMaxF64 returns running-max normalizer for float64 values.
+func NewRunningMaxF64() *RunningMaxF64 { return new(RunningMaxF64) }
+
+// NewRunningMaxU64 returns running-max normalizer for uint64 values.
+func NewRunningMaxU64() *RunningMaxU64 { return new(RunningMaxU64) }
+
+// Normalize implements FloatNorm interface. Negative, NaN
+// and infinite values are normalized to 0 and don't affect
+// the tracked maximum.
+func (n *RunningMaxF64) Normalize(w float64) float64 {
+	if math.IsNaN(w) || math.IsInf(w, 0) || w <= 0 {
+		return 0
+	}
+
+	// bit representations of non-negative floats have the same order
+	// as the floats themselves
+	bw := math.Float64bits(w)
+	for {
+		old := atomic.LoadUint64(&n.max)
+		if bw <= old {
+			return w / math.Float64frombits(old)
+		}
+		if atomic.CompareAndSwapUint64(&n.max, old, bw) {
+			return 1
+		}
+	}
+}
+
+// Max returns the maximum value seen so far.
+func (n *RunningMaxF64) Max() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&n.max))
+}
+
+// Normalize implements Uint64Norm interface.
+func (n *RunningMaxU64) Normalize(w uint64) float64 {
+	if w == 0 {
+		return 0
+	}
+
+	for {
+		old := atomic.LoadUint64(&n.max)
+		if w == old {
+			return 1
+		} else if w < old {
+			return fixed64.Float64(fixed64.Ratio(w, old))
+		}
+		if atomic.CompareAndSwapUint64(&n.max, old, w) {
+			return 1
+		}
+	}
+}
+
+// Max returns the maximum value seen so far.
+func (n *RunningMaxU64) Max() uint64 {
+	return atomic.LoadUint64(&n.max)
+}
@@ -0,0 +1,60 @@
+package normalizer
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningMaxF64(t *testing.T) {
+	n := NewRunningMaxF64()
+
+	require.Equal(t, 1.0, n.Normalize(10))
+	require.Equal(t, 0.5, n.Normalize(5))
+	require.Equal(t, 1.0, n.Normalize(40))
+	require.Equal(t, 0.25, n.Normalize(10))
+	require.Equal(t, 40.0, n.Max())
+
+	require.Equal(t, 0.0, n.Normalize(-1))
+	require.Equal(t, 0.0, n.Normalize(math.NaN()))
+	require.Equal(t, 0.0, n.Normalize(math.Inf(1)))
+	require.Equal(t, 40.0, n.Max())
+}
+
+func TestRunningMaxU64(t *testing.T) {
+	n := NewRunningMaxU64()
+
+	require.Equal(t, 0.0, n.Normalize(0))
+	require.Equal(t, 1.0, n.Normalize(10))
+	require.Equal(t, 0.5, n.Normalize(5))
+	require.Equal(t, 1.0, n.Normalize(40))
+	require.Equal(t, 0.25, n.Normalize(10))
+	require.Equal(t, uint64(40), n.Max())
+}
+
+func TestRunningMaxConcurrent(t *testing.T) {
+	const workers, count = 8, 1000
+
+	var (
+		wg  sync.WaitGroup
+		f64 = NewRunningMaxF64()
+		u64 = NewRunningMaxU64()
+	)
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				f64.Normalize(float64(j*workers + i))
+				u64.Normalize(uint64(j*workers + i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, float64(workers*count-1), f64.Max())
+	require.Equal(t, uint64(workers*count-1), u64.Max())
+}
@@ -0,0 +1,62 @@
+package hrw
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// SortSliceByValueParallel is SortSliceByValue computing hashes of Hasher
+// elements concurrently across GOMAXPROCS workers if there are at least
+// threshold of them. It pays off for expensive Hash implementations
+// (e.g. cryptographic hashes of node keys), elements of other types are
+// hashed sequentially.
+func SortSliceByValueParallel(slice interface{}, hash uint64, threshold int) {
+	rule := prepareRuleParallel(slice, threshold)
+	if rule != nil {
+		permute(sortByDistance(len(rule), false, rule, hash, nil), reflect.Swapper(slice))
+	}
+}
+
+// SortSliceByWeightValueParallel is SortSliceByWeightValue hashing elements
+// concurrently, see SortSliceByValueParallel.
+func SortSliceByWeightValueParallel(slice interface{}, weights []float64, hash uint64, threshold int) {
+	rule := prepareRuleParallel(slice, threshold)
+	if rule != nil {
+		permute(sortByWeight(len(rule), false, rule, weights, hash, nil), reflect.Swapper(slice))
+	}
+}
+
+func prepareRuleParallel(slice interface{}, threshold int) []uint64 {
+	val := reflect.ValueOf(slice)
+	if val.Kind() != reflect.Slice || val.Len() < threshold || val.Len() == 0 {
+		return prepareRule(slice)
+	}
+	if _, ok := val.Index(0).Interface().(Hasher); !ok {
+		return prepareRule(slice)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		length  = val.Len()
+		rule    = make([]uint64, length)
+		workers = runtime.GOMAXPROCS(0)
+		chunk   = (length + workers - 1) / workers
+	)
+	for start := 0; start < length; start += chunk {
+		end := start + chunk
+		if end > length {
+			end = length
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				rule[i] = val.Index(i).Interface().(Hasher).Hash()
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return rule
+}
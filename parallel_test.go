@@ -0,0 +1,54 @@
+package hrw
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sha256Node string
+
+func (n sha256Node) Hash() uint64 {
+	sum := sha256.Sum256([]byte(n))
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+func TestSortSliceByValueParallel(t *testing.T) {
+	var (
+		hash    = Hash(testKey)
+		nodes   = make([]sha256Node, 1000)
+		weights = make([]float64, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = sha256Node("node-" + strconv.Itoa(i))
+		weights[i] = float64(i%10+1) / 10
+	}
+
+	for _, threshold := range []int{0, 10, len(nodes) + 1} {
+		expect := append([]sha256Node{}, nodes...)
+		actual := append([]sha256Node{}, nodes...)
+		SortSliceByValue(expect, hash)
+		SortSliceByValueParallel(actual, hash, threshold)
+		require.Equal(t, expect, actual)
+
+		expect = append(expect[:0], nodes...)
+		actual = append(actual[:0], nodes...)
+		SortSliceByWeightValue(expect, weights, hash)
+		SortSliceByWeightValueParallel(actual, weights, hash, threshold)
+		require.Equal(t, expect, actual)
+	}
+
+	t.Run("not hashers", func(t *testing.T) {
+		expect := []string{"a", "b", "c", "d", "e", "f"}
+		actual := append([]string{}, expect...)
+		SortSliceByValue(expect, hash)
+		SortSliceByValueParallel(actual, hash, 0)
+		require.Equal(t, expect, actual)
+
+		require.NotPanics(t, func() { SortSliceByValueParallel([]sha256Node{}, hash, 0) })
+		require.NotPanics(t, func() { SortSliceByValueParallel(10, hash, 0) })
+	})
+}
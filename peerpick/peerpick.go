@@ -0,0 +1,124 @@
+// Package peerpick implements peer picking for cache-fill protocols
+// (groupcache, mcrouter and alike): every cache key is owned by a single
+// peer chosen with rendezvous hashing, hot keys can be served by several
+// top peers and every peer excludes itself from the remote candidates.
+package peerpick
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nspcc-dev/hrw"
+)
+
+// Picker picks peers owning cache keys. It is safe for concurrent use.
+type Picker struct {
+	self string
+
+	mu      sync.RWMutex
+	peers   []string
+	hashes  []uint64
+	weights []float64
+}
+
+// New returns Picker for the peer with the given ID. Peer IDs (e.g. base
+// URLs) must be the same on all peers for them to agree on ownership.
+func New(self string) *Picker {
+	return &Picker{self: self}
+}
+
+// Set replaces the set of peers with equally weighted ones. self may be
+// among them, otherwise it never owns any key.
+func (p *Picker) Set(peers ...string) {
+	p.set(peers, nil)
+}
+
+// SetWeighted replaces the set of peers with weighted ones, weights must be
+// normalized, see hrw.ValidateWeights.
+func (p *Picker) SetWeighted(peers map[string]float64) error {
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	weights := make([]float64, len(ids))
+	for i := range ids {
+		weights[i] = peers[ids[i]]
+	}
+	if err := hrw.ValidateWeights(weights); err != nil {
+		return fmt.Errorf("invalid peer weights: %w", err)
+	}
+	p.set(ids, weights)
+	return nil
+}
+
+func (p *Picker) set(peers []string, weights []float64) {
+	hashes := make([]uint64, len(peers))
+	for i := range peers {
+		hashes[i] = hrw.Hash([]byte(peers[i]))
+	}
+
+	p.mu.Lock()
+	p.peers, p.hashes, p.weights = append([]string(nil), peers...), hashes, weights
+	p.mu.Unlock()
+}
+
+// Owners returns the first n peers for the key in HRW order, including self.
+func (p *Picker) Owners(key string, n int) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	order := p.order(key)
+	if n > len(order) {
+		n = len(order)
+	} else if n < 0 {
+		n = 0
+	}
+
+	res := make([]string, n)
+	for i := range res {
+		res[i] = p.peers[order[i]]
+	}
+	return res
+}
+
+// PickPeer returns the peer owning the key. ok is false if the key is
+// owned by self (so it must be loaded locally) or there are no peers.
+func (p *Picker) PickPeer(key string) (peer string, ok bool) {
+	return p.PickHot(key, 1)
+}
+
+// PickHot returns the peer to fetch the hot key from, which is replicated
+// to the first k peers in HRW order. ok is false if self is one of them
+// or there are no peers. Every peer consistently picks its own replica,
+// so requests for the hot key are spread across all replicas.
+func (p *Picker) PickHot(key string, k int) (peer string, ok bool) {
+	owners := p.Owners(key, k)
+	if len(owners) == 0 {
+		return "", false
+	}
+	for i := range owners {
+		if owners[i] == p.self {
+			return "", false
+		}
+	}
+	if len(owners) == 1 {
+		return owners[0], true
+	}
+
+	hashes := make([]uint64, len(owners))
+	for i := range owners {
+		hashes[i] = hrw.Hash([]byte(owners[i]))
+	}
+	return owners[hrw.Sort(hashes, hrw.Hash([]byte(p.self)))[0]], true
+}
+
+func (p *Picker) order(key string) []uint64 {
+	h := hrw.HashString(key)
+	if p.weights != nil {
+		return hrw.SortByWeight(p.hashes, p.weights, h)
+	}
+	return hrw.Sort(p.hashes, h)
+}
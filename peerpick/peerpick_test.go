@@ -0,0 +1,82 @@
+package peerpick
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var peers = []string{"http://a", "http://b", "http://c", "http://d"}
+
+func TestPickPeer(t *testing.T) {
+	pickers := make([]*Picker, len(peers))
+	for i := range peers {
+		pickers[i] = New(peers[i])
+		pickers[i].Set(peers...)
+	}
+
+	for k := 0; k < 100; k++ {
+		key := "key-" + strconv.Itoa(k)
+		owner := pickers[0].Owners(key, 1)[0]
+
+		for i, p := range pickers {
+			peer, ok := p.PickPeer(key)
+			if peers[i] == owner {
+				require.False(t, ok, "owner loads the key itself")
+			} else {
+				require.True(t, ok)
+				require.Equal(t, owner, peer)
+			}
+		}
+	}
+
+	t.Run("no peers", func(t *testing.T) {
+		_, ok := New("http://a").PickPeer("key")
+		require.False(t, ok)
+	})
+}
+
+func TestPickHot(t *testing.T) {
+	const key = "hot"
+
+	p := New("http://e")
+	p.Set(peers...)
+	replicas := p.Owners(key, 2)
+
+	peer, ok := p.PickHot(key, 2)
+	require.True(t, ok)
+	require.Contains(t, replicas, peer)
+
+	again, _ := p.PickHot(key, 2)
+	require.Equal(t, peer, again)
+
+	// different clients use different replicas
+	used := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		c := New("client-" + strconv.Itoa(i))
+		c.Set(peers...)
+		peer, ok := c.PickHot(key, 2)
+		require.True(t, ok)
+		used[peer] = true
+	}
+	require.Len(t, used, 2)
+
+	t.Run("self is replica", func(t *testing.T) {
+		self := New(replicas[1])
+		self.Set(peers...)
+		_, ok := self.PickHot(key, 2)
+		require.False(t, ok)
+	})
+}
+
+func TestSetWeighted(t *testing.T) {
+	p := New("self")
+	require.Error(t, p.SetWeighted(map[string]float64{"a": 2}))
+
+	require.NoError(t, p.SetWeighted(map[string]float64{"http://a": 1, "http://b": 0}))
+	for k := 0; k < 20; k++ {
+		require.Equal(t, []string{"http://a"}, p.Owners("key-"+strconv.Itoa(k), 1))
+	}
+	require.Len(t, p.Owners("key", 10), 2)
+}
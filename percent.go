@@ -0,0 +1,47 @@
+package hrw
+
+import (
+	"errors"
+	"math"
+)
+
+// PercentEpsilon is the allowed deviation of the sum of percentage
+// weights from 100.
+const PercentEpsilon = 1e-6
+
+// PercentWeights converts percentage weights, which must be non-negative
+// and sum to 100 (within PercentEpsilon), into normalized weights by
+// dividing them by the maximal one. Note that weighted sorting keeps the
+// order of weights (node with more percents gets more objects), but
+// shares of objects are not exactly proportional to the percents.
+func PercentWeights(percents []float64) ([]float64, error) {
+	var sum, max float64
+	for _, p := range percents {
+		if math.IsNaN(p) || p < 0 || p > 100 {
+			return nil, errors.New("percentage must be between 0 and 100")
+		}
+		sum += p
+		if p > max {
+			max = p
+		}
+	}
+	if math.Abs(sum-100) > PercentEpsilon {
+		return nil, errors.New("percentages must sum to 100")
+	}
+
+	ws := make([]float64, len(percents))
+	for i := range percents {
+		ws[i] = percents[i] / max
+	}
+	return ws, nil
+}
+
+// SortByPercent receive nodes, percentage weights and hash, and sort it
+// by distance * weight, see PercentWeights.
+func SortByPercent(nodes []uint64, percents []float64, hash uint64) ([]uint64, error) {
+	ws, err := PercentWeights(percents)
+	if err != nil {
+		return nil, err
+	}
+	return SortByWeight(nodes, ws, hash), nil
+}
@@ -0,0 +1,51 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentWeights(t *testing.T) {
+	ws, err := PercentWeights([]float64{50, 25, 25, 0})
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 0.5, 0.5, 0}, ws)
+
+	_, err = PercentWeights([]float64{33.3333333, 33.3333333, 33.3333334})
+	require.NoError(t, err)
+
+	for _, ps := range [][]float64{
+		nil,
+		{50, 49},
+		{50, 51},
+		{120, -20},
+		{100, math.NaN()},
+	} {
+		_, err := PercentWeights(ps)
+		require.Error(t, err, ps)
+	}
+}
+
+func TestSortByPercent(t *testing.T) {
+	var (
+		nodes    = []uint64{1, 2, 3}
+		percents = []float64{60, 30, 10}
+		key      = make([]byte, 8)
+		counts   = make([]int, len(nodes))
+	)
+	for k := uint64(0); k < 10000; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		order, err := SortByPercent(nodes, percents, hash)
+		require.NoError(t, err)
+		require.Equal(t, SortByWeight(nodes, []float64{1, 0.5, 1.0 / 6}, hash), order)
+		counts[order[0]]++
+	}
+	require.True(t, counts[0] > counts[1] && counts[1] > counts[2], "%v", counts)
+
+	_, err := SortByPercent(nodes, []float64{60, 30}, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package ptable
+
+// File is a View over the partition table file, see Open.
+type File struct {
+	*View
+	data  []byte
+	unmap func([]byte) error
+}
+
+// Open opens partition table file. Where supported the file is
+// memory-mapped read-only, so its pages are shared between processes,
+// otherwise it is read into memory. File must be closed after use.
+func Open(path string) (*File, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := NewView(data)
+	if err != nil {
+		_ = unmap(data)
+		return nil, err
+	}
+	return &File{View: v, data: data, unmap: unmap}, nil
+}
+
+// Close releases the file, the view must not be used after that.
+func (f *File) Close() error {
+	return f.unmap(f.data)
+}
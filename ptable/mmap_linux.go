@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package ptable
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func mapFile(path string) ([]byte, func([]byte) error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := st.Size()
+	if size == 0 {
+		return nil, nil, errors.New("empty partition table file")
+	} else if int64(int(size)) != size {
+		return nil, nil, errors.New("partition table file is too big")
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, syscall.Munmap, nil
+}
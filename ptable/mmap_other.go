@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package ptable
+
+import "io/ioutil"
+
+func mapFile(path string) ([]byte, func([]byte) error, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func([]byte) error { return nil }, nil
+}
@@ -0,0 +1,216 @@
+// Package ptable implements a compact binary format for precomputed
+// partition tables: owners of a fixed number of partitions are computed
+// once and stored in a file, which can be memory-mapped read-only by many
+// processes on a host, so sidecars and short-lived tools don't recompute
+// placement at startup.
+//
+// All integers are big-endian. The file consists of:
+//
+//	header:     magic "HRWP", version (uint16), algorithm (uint16),
+//	            epoch (uint64), number of nodes (uint32),
+//	            number of partitions (uint32)
+//	node list:  for every node its ID length (uint16) and ID bytes
+//	padding:    zero bytes up to 4-byte alignment
+//	owners:     index of the owner node (uint32) for every partition
+package ptable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/nspcc-dev/hrw"
+)
+
+// Version is the current version of the format.
+const Version = 1
+
+// Placement algorithms.
+const (
+	// AlgorithmHRW places partition p on the first node returned by
+	// hrw.SortByWeight for the hash of p encoded as big-endian uint64.
+	AlgorithmHRW uint16 = 1
+)
+
+const headerSize = 4 + 2 + 2 + 8 + 4 + 4
+
+var magic = [4]byte{'H', 'R', 'W', 'P'}
+
+// Table is a partition table.
+type Table struct {
+	// Algorithm is the placement algorithm identifier.
+	Algorithm uint16
+	// Epoch is the membership epoch the table is computed for.
+	Epoch uint64
+	// Nodes contains node IDs.
+	Nodes []string
+	// Owners contains index of the owner node for every partition.
+	Owners []uint32
+}
+
+// Build computes partition table for nodes with normalized weights (nil
+// means all nodes are equal) using AlgorithmHRW.
+func Build(nodes []string, weights []float64, partitions int, epoch uint64) (*Table, error) {
+	if len(nodes) == 0 || partitions <= 0 {
+		return nil, errors.New("nodes and partitions must not be empty")
+	}
+	if weights == nil {
+		weights = make([]float64, len(nodes))
+	} else if len(weights) != len(nodes) {
+		return nil, errors.New("number of weights doesn't match number of nodes")
+	}
+	if err := hrw.ValidateWeights(weights); err != nil {
+		return nil, err
+	}
+
+	hashes := make([]uint64, len(nodes))
+	for i := range nodes {
+		hashes[i] = hrw.Hash([]byte(nodes[i]))
+	}
+
+	t := &Table{
+		Algorithm: AlgorithmHRW,
+		Epoch:     epoch,
+		Nodes:     append([]string(nil), nodes...),
+		Owners:    make([]uint32, partitions),
+	}
+	for p := range t.Owners {
+		t.Owners[p] = uint32(hrw.SortByWeight(hashes, weights, PartitionHash(p))[0])
+	}
+	return t, nil
+}
+
+// PartitionHash returns hash of the p-th partition used by AlgorithmHRW.
+func PartitionHash(p int) uint64 {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], uint64(p))
+	return hrw.Hash(key[:])
+}
+
+// Partition returns partition of the object with the given hash.
+func Partition(hash uint64, partitions int) int {
+	return int(hash % uint64(partitions))
+}
+
+// MarshalBinary encodes the table.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	if uint64(len(t.Nodes)) > math.MaxUint32 || uint64(len(t.Owners)) > math.MaxUint32 {
+		return nil, errors.New("table is too big")
+	}
+
+	size := headerSize
+	for i := range t.Nodes {
+		if len(t.Nodes[i]) > math.MaxUint16 {
+			return nil, fmt.Errorf("node ID %d is too long", i)
+		}
+		size += 2 + len(t.Nodes[i])
+	}
+	size = align(size) + 4*len(t.Owners)
+
+	buf := make([]byte, size)
+	copy(buf, magic[:])
+	binary.BigEndian.PutUint16(buf[4:], Version)
+	binary.BigEndian.PutUint16(buf[6:], t.Algorithm)
+	binary.BigEndian.PutUint64(buf[8:], t.Epoch)
+	binary.BigEndian.PutUint32(buf[16:], uint32(len(t.Nodes)))
+	binary.BigEndian.PutUint32(buf[20:], uint32(len(t.Owners)))
+
+	off := headerSize
+	for i := range t.Nodes {
+		binary.BigEndian.PutUint16(buf[off:], uint16(len(t.Nodes[i])))
+		off += 2 + copy(buf[off+2:], t.Nodes[i])
+	}
+	off = align(off)
+	for _, o := range t.Owners {
+		if int(o) >= len(t.Nodes) {
+			return nil, fmt.Errorf("owner %d is out of range", o)
+		}
+		binary.BigEndian.PutUint32(buf[off:], o)
+		off += 4
+	}
+	return buf, nil
+}
+
+// View is a read-only partition table backed by encoded data, owners are
+// read directly from it without copying.
+type View struct {
+	algorithm uint16
+	epoch     uint64
+	nodes     []string
+	owners    []byte
+}
+
+// NewView parses encoded table. data must not be modified while the view
+// is used.
+func NewView(data []byte) (*View, error) {
+	if len(data) < headerSize || string(data[:4]) != string(magic[:]) {
+		return nil, errors.New("not a partition table")
+	}
+	if v := binary.BigEndian.Uint16(data[4:]); v != Version {
+		return nil, fmt.Errorf("unsupported version %d", v)
+	}
+
+	var (
+		v = &View{
+			algorithm: binary.BigEndian.Uint16(data[6:]),
+			epoch:     binary.BigEndian.Uint64(data[8:]),
+		}
+		nodes      = binary.BigEndian.Uint32(data[16:])
+		partitions = binary.BigEndian.Uint32(data[20:])
+		off        = headerSize
+	)
+	if nodes == 0 || partitions == 0 {
+		return nil, errors.New("nodes and partitions must not be empty")
+	}
+	for i := uint32(0); i < nodes; i++ {
+		if len(data) < off+2 {
+			return nil, errors.New("truncated node list")
+		}
+		l := int(binary.BigEndian.Uint16(data[off:]))
+		off += 2
+		if len(data) < off+l {
+			return nil, errors.New("truncated node list")
+		}
+		v.nodes = append(v.nodes, string(data[off:off+l]))
+		off += l
+	}
+
+	off = align(off)
+	if off > len(data) || uint64(len(data)-off) != 4*uint64(partitions) {
+		return nil, errors.New("invalid owners size")
+	}
+	v.owners = data[off:]
+	for p := 0; p < int(partitions); p++ {
+		if v.owner(p) >= nodes {
+			return nil, fmt.Errorf("owner of partition %d is out of range", p)
+		}
+	}
+	return v, nil
+}
+
+// Algorithm returns placement algorithm identifier.
+func (v *View) Algorithm() uint16 { return v.algorithm }
+
+// Epoch returns membership epoch of the table.
+func (v *View) Epoch() uint64 { return v.epoch }
+
+// Nodes returns node IDs.
+func (v *View) Nodes() []string { return v.nodes }
+
+// Partitions returns number of partitions.
+func (v *View) Partitions() int { return len(v.owners) / 4 }
+
+// Owner returns index of the node owning the p-th partition.
+func (v *View) Owner(p int) int { return int(v.owner(p)) }
+
+// Lookup returns ID of the node owning the object with the given hash.
+func (v *View) Lookup(hash uint64) string {
+	return v.nodes[v.owner(Partition(hash, v.Partitions()))]
+}
+
+func (v *View) owner(p int) uint32 {
+	return binary.BigEndian.Uint32(v.owners[4*p:])
+}
+
+func align(n int) int { return (n + 3) &^ 3 }
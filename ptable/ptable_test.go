@@ -0,0 +1,143 @@
+package ptable
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/hrw"
+	"github.com/stretchr/testify/require"
+)
+
+var testNodes = []string{"node-a", "node-b", "node-c", "node-d"}
+
+func TestBuild(t *testing.T) {
+	tbl, err := Build(testNodes, nil, 64, 7)
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmHRW, tbl.Algorithm)
+	require.Equal(t, uint64(7), tbl.Epoch)
+	require.Len(t, tbl.Owners, 64)
+
+	hashes := make([]uint64, len(testNodes))
+	for i := range testNodes {
+		hashes[i] = hrw.Hash([]byte(testNodes[i]))
+	}
+	used := make(map[uint32]bool)
+	for p, o := range tbl.Owners {
+		require.Equal(t, hrw.Sort(hashes, PartitionHash(p))[0], uint64(o))
+		used[o] = true
+	}
+	require.Len(t, used, len(testNodes))
+
+	t.Run("weighted", func(t *testing.T) {
+		tbl, err := Build(testNodes, []float64{1, 0, 0, 0}, 16, 0)
+		require.NoError(t, err)
+		require.Equal(t, make([]uint32, 16), tbl.Owners)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Build(nil, nil, 16, 0)
+		require.Error(t, err)
+		_, err = Build(testNodes, nil, 0, 0)
+		require.Error(t, err)
+		_, err = Build(testNodes, []float64{1}, 16, 0)
+		require.Error(t, err)
+		_, err = Build(testNodes, []float64{1, 2, 1, 1}, 16, 0)
+		require.Error(t, err)
+	})
+}
+
+func TestView(t *testing.T) {
+	tbl, err := Build(testNodes, nil, 100, 42)
+	require.NoError(t, err)
+
+	data, err := tbl.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(data)%4)
+
+	v, err := NewView(data)
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmHRW, v.Algorithm())
+	require.Equal(t, uint64(42), v.Epoch())
+	require.Equal(t, testNodes, v.Nodes())
+	require.Equal(t, 100, v.Partitions())
+	for p := range tbl.Owners {
+		require.Equal(t, int(tbl.Owners[p]), v.Owner(p))
+	}
+
+	hash := hrw.Hash([]byte("object"))
+	require.Equal(t, testNodes[tbl.Owners[Partition(hash, 100)]], v.Lookup(hash))
+
+	t.Run("corrupted", func(t *testing.T) {
+		for _, d := range [][]byte{
+			nil,
+			data[:headerSize],
+			data[:len(data)-1],
+			append([]byte("XXXX"), data[4:]...),
+		} {
+			_, err := NewView(d)
+			require.Error(t, err)
+		}
+
+		bad := append([]byte(nil), data...)
+		bad[len(bad)-1] = 0xff
+		_, err := NewView(bad)
+		require.Error(t, err)
+
+		bad = append([]byte(nil), data...)
+		bad[5] = 2
+		_, err = NewView(bad)
+		require.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		for _, tbl := range []*Table{
+			{Nodes: testNodes},
+			{Owners: []uint32{}},
+			{},
+		} {
+			d, err := tbl.MarshalBinary()
+			require.NoError(t, err)
+			_, err = NewView(d)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptable")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tbl, err := Build(testNodes, nil, 32, 1)
+	require.NoError(t, err)
+	data, err := tbl.MarshalBinary()
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "table")
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	f, err := Open(path)
+	require.NoError(t, err)
+	require.Equal(t, testNodes, f.Nodes())
+	for p := range tbl.Owners {
+		require.Equal(t, int(tbl.Owners[p]), f.Owner(p))
+	}
+	require.NoError(t, f.Close())
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Open(filepath.Join(dir, "none"))
+		require.Error(t, err)
+
+		empty := filepath.Join(dir, "empty")
+		require.NoError(t, ioutil.WriteFile(empty, nil, 0644))
+		_, err = Open(empty)
+		require.Error(t, err)
+
+		garbage := filepath.Join(dir, "garbage")
+		require.NoError(t, ioutil.WriteFile(garbage, []byte("garbage garbage garbage"), 0644))
+		_, err = Open(garbage)
+		require.Error(t, err)
+	})
+}
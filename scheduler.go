@@ -0,0 +1,65 @@
+package hrw
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Scheduler assigns recurring jobs to instances and guards ownership
+// with membership epoch: an instance runs the job only if it owns it
+// in the current epoch, so jobs are not run twice while the membership
+// change propagates. It is safe for concurrent use.
+type Scheduler struct {
+	mu        sync.RWMutex
+	epoch     uint64
+	instances []uint64
+}
+
+// AssignJobs returns index of the owning instance for every job.
+// Jobs move only from and to instances which leave or join.
+func AssignJobs(jobs, instances []uint64) []int {
+	owners := make([]int, len(jobs))
+	for i := range jobs {
+		owners[i] = ElectLeader(instances, jobs[i])
+	}
+	return owners
+}
+
+// NewScheduler returns Scheduler with no instances.
+func NewScheduler() *Scheduler {
+	return new(Scheduler)
+}
+
+// Update sets instances for the epoch. Epoch must be greater than
+// the current one, stale updates are rejected.
+func (s *Scheduler) Update(instances []uint64, epoch uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if epoch <= s.epoch && s.instances != nil {
+		return fmt.Errorf("stale epoch %d, current is %d", epoch, s.epoch)
+	}
+	s.epoch = epoch
+	s.instances = append(make([]uint64, 0, len(instances)), instances...)
+	return nil
+}
+
+// Owner returns hash of the instance owning the job along with the
+// current epoch. ok is false if there are no instances.
+func (s *Scheduler) Owner(job uint64) (owner uint64, epoch uint64, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := ElectLeader(s.instances, job)
+	if i < 0 {
+		return 0, s.epoch, false
+	}
+	return s.instances[i], s.epoch, true
+}
+
+// Owns checks that self owns the job in the given epoch and that
+// the epoch is still the current one.
+func (s *Scheduler) Owns(self, job, epoch uint64) bool {
+	owner, cur, ok := s.Owner(job)
+	return ok && cur == epoch && owner == self
+}
@@ -0,0 +1,76 @@
+package hrw
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignJobs(t *testing.T) {
+	var (
+		jobs      = make([]uint64, 1000)
+		instances = make([]uint64, 5)
+	)
+	for i := range jobs {
+		jobs[i] = Hash([]byte("job-" + strconv.Itoa(i)))
+	}
+	for i := range instances {
+		instances[i] = Hash([]byte("instance-" + strconv.Itoa(i)))
+	}
+
+	owners := AssignJobs(jobs, instances)
+	counts := make(map[int]int)
+	for _, o := range owners {
+		counts[o]++
+	}
+	require.Len(t, counts, len(instances))
+
+	t.Run("minimal reassignment", func(t *testing.T) {
+		joined := append(append([]uint64{}, instances...), Hash([]byte("instance-new")))
+		for i, o := range AssignJobs(jobs, joined) {
+			if o != owners[i] {
+				require.Equal(t, len(instances), o)
+			}
+		}
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	var (
+		job       = Hash([]byte("job"))
+		instances = make([]uint64, 5)
+	)
+	for i := range instances {
+		instances[i] = Hash([]byte("instance-" + strconv.Itoa(i)))
+	}
+
+	s := NewScheduler()
+	_, _, ok := s.Owner(job)
+	require.False(t, ok)
+
+	require.NoError(t, s.Update(instances, 1))
+	owner, epoch, ok := s.Owner(job)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), epoch)
+	require.Equal(t, instances[ElectLeader(instances, job)], owner)
+	require.True(t, s.Owns(owner, job, 1))
+
+	for _, i := range instances {
+		if i != owner {
+			require.False(t, s.Owns(i, job, 1))
+		}
+	}
+
+	t.Run("stale epoch", func(t *testing.T) {
+		require.Error(t, s.Update(instances[:1], 1))
+		require.Error(t, s.Update(instances[:1], 0))
+		require.True(t, s.Owns(owner, job, 1))
+	})
+
+	t.Run("epoch guard", func(t *testing.T) {
+		require.NoError(t, s.Update(instances, 2))
+		require.False(t, s.Owns(owner, job, 1))
+		require.True(t, s.Owns(owner, job, 2))
+	})
+}
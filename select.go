@@ -0,0 +1,507 @@
+package hrw
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Spread walks order (as returned by Sort or SortByWeight) and returns
+// the first n indices such that no more than max of them share the same
+// attribute value, skipping further down the HRW order as needed.
+// attr receives an index of the node in the original slice.
+// If there are not enough suitable nodes, less than n indices are returned.
+// Non-positive n yields an empty result.
+func Spread(order []uint64, n int, attr func(i int) string, max int) []uint64 {
+	if n > len(order) {
+		n = len(order)
+	} else if n < 0 {
+		n = 0
+	}
+
+	var (
+		result = make([]uint64, 0, n)
+		caps   = newSpreadCaps([]constraint{{attr: attr, max: max}})
+	)
+	for _, i := range order {
+		if len(result) == n {
+			break
+		}
+		if caps.accept(int(i)) {
+			caps.add()
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// SelectConstrained receive nodes, hash, number of nodes to select and
+// failure domain function, and return indices of the first n nodes in HRW
+// order such that no more than maxPerDomain of them belong to the same
+// domain, see Spread. domainOf receives an index of the node in nodes.
+func SelectConstrained(nodes []uint64, hash uint64, n int, domainOf func(i int) string, maxPerDomain int) []uint64 {
+	return Spread(Sort(nodes, hash), n, domainOf, maxPerDomain)
+}
+
+// ErrZeroWeights is returned by Selector configured with ZeroWeightsError
+// policy if all candidates have zero weight.
+var ErrZeroWeights = errors.New("all weights are zero")
+
+// ZeroWeightsPolicy defines how Selector handles weights which are all zero.
+type ZeroWeightsPolicy int
+
+// Zero weights policies.
+const (
+	// ZeroWeightsUnweighted sorts nodes by distance as if there are no
+	// weights, it is the behaviour of all sorting functions.
+	ZeroWeightsUnweighted ZeroWeightsPolicy = iota
+	// ZeroWeightsError makes For return ErrZeroWeights.
+	ZeroWeightsError
+)
+
+type (
+	// Selector is a composable selection pipeline over a set of nodes.
+	// Stages are configured once and applied for every object by For.
+	// Node indices passed to the stage functions refer to the slice
+	// given to Select. Selector must not be reconfigured concurrently
+	// with For calls.
+	Selector struct {
+		nodes       []uint64
+		filters     []func(i int) bool
+		weight      func(i int) float64
+		constraints []constraint
+		limit       int
+		allow       func(i int) bool
+		boosts      []boost
+		loadTop     int
+		load        func(i int) int64
+		audit       *Audit
+		epoch       func() uint64
+		reorderer   Reorderer
+		reorderTop  int
+		zeroPolicy  ZeroWeightsPolicy
+		distance    DistanceFunc
+		adjust      func(i int) float64
+	}
+
+	// Reorderer is a hook adjusting the HRW order of the best candidates,
+	// e.g. to implement backpressure or circuit breaking. Reorder receives
+	// indices of nodes in HRW order and the object hash and returns them
+	// in the preferred order. Nodes can be dropped, but no new ones can be
+	// added.
+	Reorderer interface {
+		Reorder(candidates []uint64, hash uint64) []uint64
+	}
+
+	// InFlight tracks number of outstanding requests per node.
+	// It is safe for concurrent use.
+	InFlight struct{ counts []int64 }
+
+	constraint struct {
+		attr func(i int) string
+		max  int
+	}
+
+	// spreadCaps counts selected nodes per attribute value for every constraint.
+	spreadCaps struct {
+		constraints []constraint
+		counts      []map[string]int
+		attrs       []string
+	}
+
+	boost struct {
+		match  func(i int) bool
+		factor float64
+	}
+)
+
+// Select starts selection pipeline over nodes containing hrw hashes.
+func Select(nodes []uint64) *Selector {
+	return &Selector{nodes: nodes}
+}
+
+// Filter leaves only nodes for which f returns true. Multiple filters
+// are combined with logical AND.
+func (s *Selector) Filter(f func(i int) bool) *Selector {
+	s.filters = append(s.filters, f)
+	return s
+}
+
+// Weigh sets weight provider for nodes, weights must be normalized,
+// see ValidateWeights. Nodes are sorted by distance * weight then.
+func (s *Selector) Weigh(w func(i int) float64) *Selector {
+	s.weight = w
+	return s
+}
+
+// Adjust adds adj(i) in [-1.0, 1.0] to the normalized score of the i-th node,
+// e.g. a small penalty for nodes in maintenance windows. Normalized score is
+// (1 - distance / 2^64) * weight, for unweighted nodes it is uniformly
+// distributed in [0, 1), so penalty p makes the node lose every comparison it
+// would win by less than p: the probability of being placed before another
+// unadjusted node drops from 1/2 to (1-p)^2/2. Adjustments are deterministic,
+// so the order is still the same for the same object and adjustments.
+// Adjustment out of range makes For return an error.
+func (s *Selector) Adjust(adj func(i int) float64) *Selector {
+	s.adjust = adj
+	return s
+}
+
+// Distance replaces the function calculating distances between nodes and
+// objects, see DistanceFunc. Weights, boosts and all other stages work on
+// top of distances returned by f.
+func (s *Selector) Distance(f DistanceFunc) *Selector {
+	s.distance = f
+	return s
+}
+
+// ZeroWeights sets the policy for the case when all candidates have zero
+// weight (after boosts), see ZeroWeightsPolicy.
+func (s *Selector) ZeroWeights(p ZeroWeightsPolicy) *Selector {
+	s.zeroPolicy = p
+	return s
+}
+
+// Constrain limits number of selected nodes sharing the same attribute
+// value to max, see Spread. Multiple constraints must be satisfied
+// simultaneously.
+func (s *Selector) Constrain(attr func(i int) string, max int) *Selector {
+	s.constraints = append(s.constraints, constraint{attr: attr, max: max})
+	return s
+}
+
+// Limit sets maximum number of selected nodes, zero means no limit.
+func (s *Selector) Limit(n int) *Selector {
+	s.limit = n
+	return s
+}
+
+// Boost multiplies scores of nodes for which match returns true by factor,
+// which must not be less than 1. It is intended for locality preference:
+// nodes on the same host or in the same zone as the caller get a bonus,
+// while the result stays deterministic for the given caller and object.
+// Nodes are sorted as if they have weights equal to 1 if no weights
+// are provided.
+func (s *Selector) Boost(match func(i int) bool, factor float64) *Selector {
+	s.boosts = append(s.boosts, boost{match: match, factor: factor})
+	return s
+}
+
+// RateLimit makes selection cooperate with per-node rate limits. allow is
+// consulted in HRW order only for the nodes which would be selected, e.g.
+// it can take a token from the node's bucket. Nodes for which it returns
+// false are demoted: they are selected only if there are not enough other
+// nodes satisfying constraints.
+func (s *Selector) RateLimit(allow func(i int) bool) *Selector {
+	s.allow = allow
+	return s
+}
+
+// LeastLoaded makes the selection prefer the least loaded node among the
+// first k nodes in HRW order. It is a bounded deviation from HRW: nodes keep
+// affinity to objects, but a slow node with many outstanding requests is not
+// piled on. load must return number of outstanding requests, see InFlight.
+// Negative k makes For return an error.
+func (s *Selector) LeastLoaded(k int, load func(i int) int64) *Selector {
+	s.loadTop, s.load = k, load
+	return s
+}
+
+// Reorder makes selection pass the first k candidates in HRW order
+// (all if k is not positive) through r. It is applied after LeastLoaded
+// balancing and before constraints, rate limits and the limit, so r sees
+// a bit more nodes than will be selected and can demote some of them.
+func (s *Selector) Reorder(r Reorderer, k int) *Selector {
+	s.reorderer, s.reorderTop = r, k
+	return s
+}
+
+// Audit records every selection made by For into a. epoch returns the
+// membership epoch stored with decisions, it can be nil.
+func (s *Selector) Audit(a *Audit, epoch func() uint64) *Selector {
+	s.audit, s.epoch = a, epoch
+	return s
+}
+
+// NewInFlight returns InFlight tracker for n nodes.
+func NewInFlight(n int) *InFlight {
+	return &InFlight{counts: make([]int64, n)}
+}
+
+// Acquire registers new outstanding request to the i-th node.
+func (f *InFlight) Acquire(i int) { atomic.AddInt64(&f.counts[i], 1) }
+
+// Release registers completion of the request to the i-th node.
+func (f *InFlight) Release(i int) { atomic.AddInt64(&f.counts[i], -1) }
+
+// Load returns number of outstanding requests to the i-th node.
+func (f *InFlight) Load(i int) int64 { return atomic.LoadInt64(&f.counts[i]) }
+
+// For returns indices of nodes selected for the object with the given hash
+// in HRW order.
+func (s *Selector) For(hash uint64) ([]uint64, error) {
+	if s.load != nil && s.loadTop < 0 {
+		return nil, errors.New("number of least loaded candidates must not be negative")
+	}
+
+	cand := s.candidates()
+
+	distFunc := s.distance
+	if distFunc == nil {
+		distFunc = distance
+	}
+	dist := make([]uint64, len(cand))
+	for i := range cand {
+		dist[i] = distFunc(s.nodes[cand[i]], hash)
+	}
+
+	weights, err := s.weights(cand)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ind []int
+		// ties are possible with custom distance, see NodeHashTieBreak
+		tie = func(i, j int) bool { return s.nodes[cand[i]] < s.nodes[cand[j]] }
+	)
+	switch {
+	case s.adjust != nil:
+		if ind, err = s.adjustedOrder(cand, dist, weights); err != nil {
+			return nil, err
+		}
+	case weights != nil:
+		ind = weightOrder(dist, weights, tie)
+	default:
+		ind = distanceOrder(dist, tie)
+	}
+
+	for i := range ind {
+		ind[i] = cand[ind[i]]
+	}
+	if s.load != nil {
+		s.balance(ind)
+	}
+	if s.reorderer != nil {
+		if ind, err = s.reorder(ind, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	result := s.constrain(ind, s.limit)
+	if s.audit != nil {
+		d := Decision{Hash: hash, Nodes: result, Time: time.Now()}
+		if s.epoch != nil {
+			d.Epoch = s.epoch()
+		}
+		s.audit.Record(d)
+	}
+	return result, nil
+}
+
+// weights returns weights of candidates with boosts applied
+// or nil if nodes must be sorted by distance only.
+func (s *Selector) weights(cand []int) ([]float64, error) {
+	if s.weight == nil && len(s.boosts) == 0 {
+		return nil, nil
+	}
+
+	weights := make([]float64, len(cand))
+	for i := range cand {
+		weights[i] = NormalizedMaxWeight
+		if s.weight != nil {
+			weights[i] = s.weight(cand[i])
+		}
+	}
+	if err := ValidateWeights(weights); err != nil {
+		return nil, err
+	}
+
+	for _, b := range s.boosts {
+		if math.IsNaN(b.factor) || b.factor < 1 {
+			return nil, errors.New("boost factor must not be less than 1")
+		}
+		for i := range cand {
+			if b.match(cand[i]) {
+				weights[i] *= b.factor
+			}
+		}
+	}
+
+	if s.zeroPolicy == ZeroWeightsError && len(weights) != 0 && weights[0] == 0 && allSameF64(weights) {
+		return nil, ErrZeroWeights
+	}
+	return weights, nil
+}
+
+// adjustedOrder returns permutation of candidates sorted by normalized
+// scores with adjustments applied, see Adjust.
+func (s *Selector) adjustedOrder(cand []int, dist []uint64, weights []float64) ([]int, error) {
+	scores := make([]float64, len(cand))
+	for i := range cand {
+		a := s.adjust(cand[i])
+		if math.IsNaN(a) || a < -1 || a > 1 {
+			return nil, fmt.Errorf("adjustment %v of node %d is out of range", a, cand[i])
+		}
+
+		w := NormalizedMaxWeight
+		if weights != nil {
+			w = weights[i]
+		}
+		scores[i] = math.Ldexp(weightedScore(dist[i], w), -64) + a
+	}
+
+	st, ind := distSorter(dist)
+	st.less = func(i, j int) bool {
+		ii, jj := ind[i], ind[j]
+		if scores[ii] != scores[jj] {
+			return scores[ii] > scores[jj]
+		}
+		if dist[ii] != dist[jj] {
+			return dist[ii] < dist[jj]
+		}
+		// ties are possible with custom distance, see NodeHashTieBreak
+		return s.nodes[cand[ii]] < s.nodes[cand[jj]]
+	}
+	sort.Sort(st)
+	return ind, nil
+}
+
+func (s *Selector) candidates() []int {
+	cand := make([]int, 0, len(s.nodes))
+loop:
+	for i := range s.nodes {
+		for _, f := range s.filters {
+			if !f(i) {
+				continue loop
+			}
+		}
+		cand = append(cand, i)
+	}
+	return cand
+}
+
+// balance orders the first loadTop nodes by load keeping HRW order for
+// nodes with the same load. It is applied before constraints and rate
+// limits, so these see the balanced order and demoted nodes stay demoted.
+func (s *Selector) balance(order []int) {
+	top := order
+	if s.loadTop < len(top) {
+		top = top[:s.loadTop]
+	}
+
+	loads := make([]int64, len(top))
+	for i := range top {
+		loads[i] = s.load(top[i])
+	}
+	sort.Stable(&sorter{
+		l:    len(top),
+		less: func(i, j int) bool { return loads[i] < loads[j] },
+		swap: func(i, j int) {
+			top[i], top[j] = top[j], top[i]
+			loads[i], loads[j] = loads[j], loads[i]
+		},
+	})
+}
+
+// reorder applies Reorderer to the first reorderTop nodes of order.
+func (s *Selector) reorder(order []int, hash uint64) ([]int, error) {
+	k := len(order)
+	if s.reorderTop > 0 && s.reorderTop < k {
+		k = s.reorderTop
+	}
+
+	var (
+		top  = make([]uint64, k)
+		seen = make(map[uint64]bool, k)
+	)
+	for i := range top {
+		top[i] = uint64(order[i])
+		seen[top[i]] = false
+	}
+
+	res := make([]int, 0, len(order))
+	for _, i := range s.reorderer.Reorder(top, hash) {
+		if used, ok := seen[i]; !ok || used {
+			return nil, fmt.Errorf("reorderer returned unexpected node %d", i)
+		}
+		seen[i] = true
+		res = append(res, int(i))
+	}
+	return append(res, order[k:]...), nil
+}
+
+func (s *Selector) constrain(order []int, limit int) []uint64 {
+	n := len(order)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	var (
+		result = make([]uint64, 0, n)
+		caps   = newSpreadCaps(s.constraints)
+	)
+	add := func(i int) {
+		caps.add()
+		result = append(result, uint64(i))
+	}
+
+	var demoted []int
+	for _, i := range order {
+		if len(result) == n {
+			return result
+		}
+		if !caps.accept(i) {
+			continue
+		}
+		if s.allow != nil && !s.allow(i) {
+			demoted = append(demoted, i)
+			continue
+		}
+		add(i)
+	}
+
+	for _, i := range demoted {
+		if len(result) == n {
+			break
+		}
+		if caps.accept(i) {
+			add(i)
+		}
+	}
+	return result
+}
+
+func newSpreadCaps(cs []constraint) *spreadCaps {
+	c := &spreadCaps{
+		constraints: cs,
+		counts:      make([]map[string]int, len(cs)),
+		attrs:       make([]string, len(cs)),
+	}
+	for i := range c.counts {
+		c.counts[i] = make(map[string]int)
+	}
+	return c
+}
+
+// accept checks whether the i-th node can be added without exceeding
+// any of the constraints. Attributes of the node are kept for add.
+func (c *spreadCaps) accept(i int) bool {
+	for j, cs := range c.constraints {
+		c.attrs[j] = cs.attr(i)
+		if c.counts[j][c.attrs[j]] >= cs.max {
+			return false
+		}
+	}
+	return true
+}
+
+// add counts the node last accepted.
+func (c *spreadCaps) add() {
+	for j := range c.constraints {
+		c.counts[j][c.attrs[j]]++
+	}
+}
@@ -0,0 +1,557 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpread(t *testing.T) {
+	var (
+		nodes = make([]uint64, 9)
+		zones = []string{"a", "a", "a", "b", "b", "b", "c", "c", "c"}
+		key   = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	zone := func(i int) string { return zones[i] }
+
+	for k := uint64(0); k < 100; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		order := Sort(nodes, Hash(key))
+
+		res := Spread(order, 6, zone, 2)
+		require.Len(t, res, 6)
+
+		count := make(map[string]int)
+		prev := -1
+		for _, i := range res {
+			count[zones[i]]++
+
+			// result must be a subsequence of order
+			pos := indexOf(order, i)
+			require.True(t, pos > prev)
+			prev = pos
+		}
+		require.Equal(t, map[string]int{"a": 2, "b": 2, "c": 2}, count)
+		require.Equal(t, order[0], res[0])
+	}
+
+	t.Run("not enough nodes", func(t *testing.T) {
+		order := Sort(nodes, Hash(testKey))
+		require.Len(t, Spread(order, 6, zone, 1), 3)
+		require.Len(t, Spread(order, 20, zone, 5), 9)
+		require.Empty(t, Spread(order, 3, zone, 0))
+		require.Empty(t, Spread(order, 0, zone, 2))
+		require.Empty(t, Spread(order, -1, zone, 2))
+	})
+}
+
+func TestSelectConstrained(t *testing.T) {
+	var (
+		nodes  = make([]uint64, 8)
+		racks  = []string{"r1", "r1", "r1", "r1", "r1", "r2", "r2", "r3"}
+		domain = func(i int) string { return racks[i] }
+		key    = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	for k := uint64(0); k < 100; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		res := SelectConstrained(nodes, hash, 3, domain, 1)
+		require.Len(t, res, 3)
+		require.Equal(t, Spread(Sort(nodes, hash), 3, domain, 1), res)
+		require.Equal(t, Sort(nodes, hash)[0], res[0])
+
+		// only 2 nodes from r1 fit
+		res = SelectConstrained(nodes, hash, 8, domain, 2)
+		require.Len(t, res, 5)
+	}
+
+	require.Equal(t, Sort(nodes, 0)[:4], SelectConstrained(nodes, 0, 4, domain, 8))
+	require.Empty(t, SelectConstrained(nil, 0, 3, domain, 1))
+}
+
+func indexOf(s []uint64, v uint64) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestSelector(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 12)
+		zones = make([]string, len(nodes))
+		racks = make([]string, len(nodes))
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+		zones[i] = strconv.Itoa(i % 3)
+		racks[i] = strconv.Itoa(i % 4)
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		res, err := Select(nodes).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, hash), res)
+
+		res, err = Select(nodes).Limit(3).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, hash)[:3], res)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		res, err := Select(nodes).
+			Filter(func(i int) bool { return i%2 == 0 }).
+			Filter(func(i int) bool { return i != 4 }).
+			For(hash)
+		require.NoError(t, err)
+
+		var expect []uint64
+		for _, i := range Sort(nodes, hash) {
+			if i%2 == 0 && i != 4 {
+				expect = append(expect, i)
+			}
+		}
+		require.Equal(t, expect, res)
+	})
+
+	t.Run("weigh", func(t *testing.T) {
+		weights := make([]float64, len(nodes))
+		for i := range weights {
+			weights[i] = float64(i+1) / float64(len(nodes))
+		}
+		res, err := Select(nodes).Weigh(func(i int) float64 { return weights[i] }).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, SortByWeight(nodes, weights, hash), res)
+
+		_, err = Select(nodes).Weigh(func(i int) float64 { return 2 }).For(hash)
+		require.Error(t, err)
+	})
+
+	t.Run("constrain", func(t *testing.T) {
+		s := Select(nodes).
+			Constrain(func(i int) string { return zones[i] }, 2).
+			Constrain(func(i int) string { return racks[i] }, 1).
+			Limit(6)
+
+		for k := 0; k < 100; k++ {
+			res, err := s.For(Hash([]byte(strconv.Itoa(k))))
+			require.NoError(t, err)
+			require.Len(t, res, 4)
+
+			zc, rc := make(map[string]int), make(map[string]int)
+			for _, i := range res {
+				zc[zones[i]]++
+				rc[racks[i]]++
+			}
+			for _, c := range zc {
+				require.True(t, c <= 2)
+			}
+			require.Len(t, rc, 4)
+		}
+	})
+}
+
+func TestSelectorRateLimit(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	order := Sort(nodes, hash)
+
+	var (
+		tokens = map[int]int{int(order[0]): 0, int(order[1]): 1}
+		asked  []int
+	)
+	allow := func(i int) bool {
+		asked = append(asked, i)
+		if n, ok := tokens[i]; ok {
+			if n == 0 {
+				return false
+			}
+			tokens[i]--
+		}
+		return true
+	}
+
+	s := Select(nodes).RateLimit(allow).Limit(2)
+
+	res, err := s.For(hash)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{order[1], order[2]}, res)
+	require.Equal(t, []int{int(order[0]), int(order[1]), int(order[2])}, asked)
+
+	t.Run("demoted nodes fill the rest", func(t *testing.T) {
+		asked = nil
+		res, err := s.Limit(6).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, append(append([]uint64{}, order[2:]...), order[0], order[1]), res)
+	})
+}
+
+func TestSelectorBoost(t *testing.T) {
+	var (
+		nodes = make([]uint64, 10)
+		local = func(i int) bool { return i < 2 }
+		key   = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	const keys = 10000
+	var plain, boosted int
+	s := Select(nodes).Boost(local, 4).Limit(1)
+	for k := uint64(0); k < keys; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		if local(int(Sort(nodes, hash)[0])) {
+			plain++
+		}
+
+		res, err := s.For(hash)
+		require.NoError(t, err)
+		if local(int(res[0])) {
+			boosted++
+		}
+
+		again, _ := s.For(hash)
+		require.Equal(t, res, again)
+	}
+	require.True(t, boosted > 2*plain, "boosted %d, plain %d", boosted, plain)
+
+	t.Run("no boost", func(t *testing.T) {
+		res, err := Select(nodes).Boost(local, 1).For(Hash(testKey))
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, Hash(testKey)), res)
+	})
+
+	t.Run("invalid factor", func(t *testing.T) {
+		_, err := Select(nodes).Boost(local, 0.5).For(Hash(testKey))
+		require.Error(t, err)
+	})
+}
+
+func TestSelectorLeastLoaded(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	order := Sort(nodes, hash)
+
+	f := NewInFlight(len(nodes))
+	s := Select(nodes).LeastLoaded(3, f.Load).Limit(1)
+
+	res, err := s.For(hash)
+	require.NoError(t, err)
+	require.Equal(t, order[:1], res)
+
+	f.Acquire(int(order[0]))
+	res, _ = s.For(hash)
+	require.Equal(t, order[1:2], res)
+
+	f.Acquire(int(order[1]))
+	f.Acquire(int(order[2]))
+	res, _ = s.For(hash)
+	require.Equal(t, order[:1], res, "all top nodes have the same load")
+
+	f.Acquire(int(order[0]))
+	res, _ = s.For(hash)
+	require.Equal(t, order[1:2], res)
+
+	// nodes beyond top-k are never preferred
+	f.Acquire(int(order[2]))
+	f.Acquire(int(order[2]))
+	res, _ = s.For(hash)
+	require.Equal(t, order[1:2], res)
+
+	t.Run("full order", func(t *testing.T) {
+		res, err := Select(nodes).LeastLoaded(3, f.Load).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{order[1], order[0], order[2]}, res[:3])
+		require.Equal(t, order[3:], res[3:])
+	})
+
+	t.Run("release", func(t *testing.T) {
+		for i := range nodes {
+			for f.Load(i) > 0 {
+				f.Release(i)
+			}
+		}
+		res, _ := s.For(hash)
+		require.Equal(t, order[:1], res)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		var asked int
+		allow := func(i int) bool {
+			asked++
+			return i != int(order[0])
+		}
+
+		res, err := Select(nodes).LeastLoaded(4, f.Load).RateLimit(allow).Limit(1).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, order[1:2], res)
+		require.Equal(t, 2, asked, "only nodes to be selected are consulted")
+
+		// demoted node isn't moved forward even if it is the least loaded one
+		f.Acquire(int(order[1]))
+		f.Acquire(int(order[2]))
+		res, err = Select(nodes).LeastLoaded(3, f.Load).RateLimit(allow).Limit(3).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, order[1:4], res)
+	})
+
+	t.Run("negative k", func(t *testing.T) {
+		_, err := Select(nodes).LeastLoaded(-1, f.Load).For(hash)
+		require.Error(t, err)
+	})
+}
+
+func TestSelectorAudit(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	a, _ := NewAudit(10)
+	res, err := Select(nodes).Limit(2).Audit(a, func() uint64 { return 42 }).For(hash)
+	require.NoError(t, err)
+
+	ds := a.Dump()
+	require.Len(t, ds, 1)
+	require.Equal(t, hash, ds[0].Hash)
+	require.Equal(t, res, ds[0].Nodes)
+	require.Equal(t, uint64(42), ds[0].Epoch)
+	require.False(t, ds[0].Time.IsZero())
+}
+
+type reorderFunc func([]uint64, uint64) []uint64
+
+func (f reorderFunc) Reorder(c []uint64, h uint64) []uint64 { return f(c, h) }
+
+func TestSelectorReorder(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	order := Sort(nodes, hash)
+
+	var seen []uint64
+	reverse := reorderFunc(func(c []uint64, h uint64) []uint64 {
+		require.Equal(t, hash, h)
+		seen = append([]uint64{}, c...)
+		res := make([]uint64, len(c))
+		for i := range c {
+			res[len(c)-1-i] = c[i]
+		}
+		return res
+	})
+
+	res, err := Select(nodes).Reorder(reverse, 3).Limit(4).For(hash)
+	require.NoError(t, err)
+	require.Equal(t, order[:3], seen)
+	require.Equal(t, []uint64{order[2], order[1], order[0], order[3]}, res)
+
+	t.Run("drop", func(t *testing.T) {
+		skip := reorderFunc(func(c []uint64, _ uint64) []uint64 { return c[1:] })
+		res, err := Select(nodes).Reorder(skip, 0).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, order[1:], res)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		dup := reorderFunc(func(c []uint64, _ uint64) []uint64 { return append(c, c[0]) })
+		_, err := Select(nodes).Reorder(dup, 2).For(hash)
+		require.Error(t, err)
+
+		unknown := reorderFunc(func(c []uint64, _ uint64) []uint64 { return []uint64{order[5]} })
+		_, err = Select(nodes).Reorder(unknown, 2).For(hash)
+		require.Error(t, err)
+	})
+}
+
+func TestSelectorZeroWeights(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+		zero  = func(int) float64 { return 0 }
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	res, err := Select(nodes).Weigh(zero).For(hash)
+	require.NoError(t, err)
+	require.Equal(t, Sort(nodes, hash), res)
+
+	_, err = Select(nodes).Weigh(zero).ZeroWeights(ZeroWeightsError).For(hash)
+	require.True(t, errors.Is(err, ErrZeroWeights))
+
+	t.Run("some weights", func(t *testing.T) {
+		w := func(i int) float64 { return float64(i % 2) }
+		_, err := Select(nodes).Weigh(w).ZeroWeights(ZeroWeightsError).For(hash)
+		require.NoError(t, err)
+	})
+
+	t.Run("no candidates", func(t *testing.T) {
+		none := func(int) bool { return false }
+		res, err := Select(nodes).Filter(none).Weigh(zero).ZeroWeights(ZeroWeightsError).For(hash)
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+}
+
+func TestSelectorDistance(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 6)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	res, err := Select(nodes).Distance(Distance).For(hash)
+	require.NoError(t, err)
+	require.Equal(t, Sort(nodes, hash), res)
+
+	// plain XOR distance orders nodes by their hashes for zero object hash
+	xor := func(node, object uint64) uint64 { return node ^ object }
+	res, err = Select(nodes).Distance(xor).For(0)
+	require.NoError(t, err)
+	for i := 1; i < len(res); i++ {
+		require.True(t, nodes[res[i-1]] < nodes[res[i]])
+	}
+
+	t.Run("weighted", func(t *testing.T) {
+		w := func(i int) float64 { return []float64{1, 0.5, 0.2, 1, 0.3, 0.9}[i] }
+		res, err := Select(nodes).Weigh(w).Distance(Distance).For(hash)
+		require.NoError(t, err)
+		require.Equal(t, SortByWeight(nodes, []float64{1, 0.5, 0.2, 1, 0.3, 0.9}, hash), res)
+	})
+}
+
+func TestDistanceV2(t *testing.T) {
+	require.Equal(t, uint64(0), DistanceV2(0, 0))
+	require.Equal(t, uint64(0x3c02aa47758292bd), DistanceV2(1, 0))
+	require.Equal(t, uint64(0x6d97305f56288c62), DistanceV2(0x0123456789abcdef, 0))
+	require.Equal(t, DistanceV2(5, 3), DistanceV2(6, 0))
+
+	var (
+		nodes  = []uint64{1, 2, 3, 4, 5}
+		key    = make([]byte, 8)
+		counts = make([]int, len(nodes))
+		moved  int
+		total  = 10000
+	)
+	for i := 0; i < total; i++ {
+		binary.BigEndian.PutUint64(key, uint64(i))
+		hash := Hash(key)
+
+		res, err := Select(nodes).Distance(DistanceV2).For(hash)
+		require.NoError(t, err)
+		counts[res[0]]++
+		if res[0] != Sort(nodes, hash)[0] {
+			moved++
+		}
+	}
+	for _, c := range counts {
+		require.InDelta(t, total/len(nodes), c, float64(total)/20)
+	}
+	// placement is independent of the default one
+	require.True(t, moved > total/2, "moved %d", moved)
+}
+
+func TestSelectorAdjust(t *testing.T) {
+	var (
+		nodes = make([]uint64, 10)
+		key   = make([]byte, 8)
+		none  = func(int) float64 { return 0 }
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	t.Run("zero adjustments", func(t *testing.T) {
+		w := func(i int) float64 { return float64(i+1) / 10 }
+		for k := uint64(0); k < 100; k++ {
+			binary.BigEndian.PutUint64(key, k)
+			hash := Hash(key)
+
+			res, err := Select(nodes).Adjust(none).For(hash)
+			require.NoError(t, err)
+			require.Equal(t, Sort(nodes, hash), res)
+
+			expect, _ := Select(nodes).Weigh(w).For(hash)
+			res, err = Select(nodes).Weigh(w).Adjust(none).For(hash)
+			require.NoError(t, err)
+			require.Equal(t, expect, res)
+		}
+	})
+
+	t.Run("penalty", func(t *testing.T) {
+		const (
+			keys    = 10000
+			penalty = 0.2
+		)
+		var (
+			first int
+			adj   = func(i int) float64 {
+				if i == 0 {
+					return -penalty
+				}
+				return 0
+			}
+			s = Select(nodes).Adjust(adj).Limit(1)
+		)
+		for k := uint64(0); k < keys; k++ {
+			binary.BigEndian.PutUint64(key, k)
+			res, err := s.For(Hash(key))
+			require.NoError(t, err)
+			if res[0] == 0 {
+				first++
+			}
+		}
+		// unadjusted node is first in 1/10 of cases, the penalized one
+		// in (1-p)^10/10 of cases, i.e. about 1%
+		require.True(t, first > 0 && first < keys/40, "first %d times", first)
+
+		res, err := Select(nodes).Adjust(func(int) float64 { return 1 }).For(0)
+		require.NoError(t, err)
+		require.Equal(t, Sort(nodes, 0), res)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := Select(nodes).Adjust(func(int) float64 { return 1.5 }).For(0)
+		require.Error(t, err)
+		_, err = Select(nodes).Adjust(func(int) float64 { return math.NaN() }).For(0)
+		require.Error(t, err)
+	})
+}
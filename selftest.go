@@ -0,0 +1,60 @@
+package hrw
+
+import "fmt"
+
+// Known-answer vectors used by SelfTest.
+var (
+	katDistance = []struct{ x, y, d uint64 }{
+		{0x1, 0x2, 0xb5181c509f8d8ce},
+		{0xff51afd7ed558ccd, 0xc4ceb9fe1a85ec53, 0x3e8591f6dcf154f8},
+		{0xffffffffffffffff, 0x123456789abcdef, 0x3ebebcc1f4a6fd7},
+	}
+
+	katHash = []struct {
+		key  string
+		hash uint64
+	}{
+		{"hrw", 0x4875b41210993044},
+		{"0xff51afd7ed558ccd", 0x498ae503303ca9e7},
+		{"/examples/object-key", 0x38f9634159e89727},
+	}
+
+	katWeighted = struct {
+		key     string
+		nodes   []uint64
+		weights []float64
+		order   []uint64
+	}{
+		key:     "0xff51afd7ed558ccd",
+		nodes:   []uint64{1, 2, 3, 4, 5, 6},
+		weights: []float64{1, 0.3, 1, 0.2, 0.7, 0.2},
+		order:   []uint64{4, 2, 0, 1, 5, 3},
+	}
+)
+
+// SelfTest validates distance function, default hash function and
+// weighted ordering against embedded known-answer vectors. It can be
+// used as a cheap runtime guard that the binary places data the same
+// way as any other one does.
+func SelfTest() error {
+	for _, v := range katDistance {
+		if d := distance(v.x, v.y); d != v.d {
+			return fmt.Errorf("distance(%#x, %#x) = %#x, expected %#x", v.x, v.y, d, v.d)
+		}
+	}
+
+	for _, v := range katHash {
+		if h := Hash([]byte(v.key)); h != v.hash {
+			return fmt.Errorf("hash of %q = %#x, expected %#x", v.key, h, v.hash)
+		}
+	}
+
+	v := katWeighted
+	order := SortByWeight(v.nodes, v.weights, Hash([]byte(v.key)))
+	for i := range order {
+		if order[i] != v.order[i] {
+			return fmt.Errorf("weighted order %v, expected %v", order, v.order)
+		}
+	}
+	return nil
+}
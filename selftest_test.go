@@ -0,0 +1,19 @@
+package hrw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	require.NoError(t, SelfTest())
+
+	t.Run("broken vector", func(t *testing.T) {
+		old := katHash[0].hash
+		katHash[0].hash++
+		defer func() { katHash[0].hash = old }()
+
+		require.Error(t, SelfTest())
+	})
+}
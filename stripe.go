@@ -0,0 +1,102 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// StripeKeys derives n child keys from the object hash, one for every
+// stripe (chunk) of the object. i-th key is a hash of base and i
+// encoded as big-endian uint64 values. Non-positive n yields no keys.
+func StripeKeys(base uint64, n int) []uint64 {
+	if n < 0 {
+		n = 0
+	}
+
+	var (
+		keys = make([]uint64, n)
+		buf  = make([]byte, 16)
+	)
+	binary.BigEndian.PutUint64(buf, base)
+	for i := range keys {
+		binary.BigEndian.PutUint64(buf[8:], uint64(i))
+		keys[i] = Hash(buf)
+	}
+	return keys
+}
+
+// StripeOwners returns index of the HRW owner from nodes for
+// every one of n stripes of the object, see StripeKeys.
+func StripeOwners(nodes []uint64, base uint64, n int) []uint64 {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	keys := StripeKeys(base, n)
+	for i := range keys {
+		keys[i] = uint64(closest(nodes, keys[i]))
+	}
+	return keys
+}
+
+// closest returns index of the node with minimal distance to hash.
+func closest(nodes []uint64, hash uint64) int {
+	var (
+		best int
+		min  = distance(nodes[0], hash)
+	)
+	for i := 1; i < len(nodes); i++ {
+		if d := distance(nodes[i], hash); d < min {
+			best, min = i, d
+		}
+	}
+	return best
+}
+
+// PlaceErasure assigns nodes to data and parity parts of the erasure-coded
+// object. Every part is placed on its own node using HRW over the part key
+// (see StripeKeys), so the node holding i-th part changes only if it is
+// no longer the best one for this part. If domain is not nil, all parts
+// are placed in distinct failure domains. Both data and parity contain
+// indices of nodes. An error is returned if there are not enough nodes
+// or domains or if any of the counts is negative.
+func PlaceErasure(nodes []uint64, hash uint64, data, parity int, domain func(i int) string) ([]uint64, []uint64, error) {
+	if data < 0 || parity < 0 {
+		return nil, nil, errors.New("number of parts must not be negative")
+	}
+
+	var (
+		keys    = StripeKeys(hash, data+parity)
+		result  = make([]uint64, len(keys))
+		used    = make([]bool, len(nodes))
+		domains = make(map[string]struct{})
+	)
+
+	for r := range keys {
+		best := -1
+		var min uint64
+		for i := range nodes {
+			if used[i] {
+				continue
+			}
+			if domain != nil {
+				if _, ok := domains[domain(i)]; ok {
+					continue
+				}
+			}
+			if d := distance(nodes[i], keys[r]); best == -1 || d < min {
+				best, min = i, d
+			}
+		}
+		if best == -1 {
+			return nil, nil, errors.New("not enough nodes to place all parts")
+		}
+
+		used[best] = true
+		if domain != nil {
+			domains[domain(best)] = struct{}{}
+		}
+		result[r] = uint64(best)
+	}
+	return result[:data:data], result[data:], nil
+}
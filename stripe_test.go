@@ -0,0 +1,117 @@
+package hrw
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripeKeys(t *testing.T) {
+	hash := Hash(testKey)
+
+	keys := StripeKeys(hash, 8)
+	require.Len(t, keys, 8)
+	require.Equal(t, keys, StripeKeys(hash, 8))
+	require.Equal(t, keys[:4], StripeKeys(hash, 4))
+
+	seen := make(map[uint64]struct{})
+	for _, k := range keys {
+		seen[k] = struct{}{}
+	}
+	require.Len(t, seen, 8)
+
+	require.NotEqual(t, keys, StripeKeys(hash+1, 8))
+	require.Empty(t, StripeKeys(hash, 0))
+	require.Empty(t, StripeKeys(hash, -1))
+}
+
+func TestStripeOwners(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 10)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+
+	owners := StripeOwners(nodes, hash, 16)
+	require.Len(t, owners, 16)
+	for i, k := range StripeKeys(hash, 16) {
+		require.Equal(t, Sort(nodes, k)[0], owners[i])
+	}
+
+	// stripes are spread across nodes
+	seen := make(map[uint64]struct{})
+	for _, o := range owners {
+		seen[o] = struct{}{}
+	}
+	require.True(t, len(seen) > 1)
+
+	require.Nil(t, StripeOwners(nil, hash, 4))
+	require.Empty(t, StripeOwners(nodes, hash, -1))
+}
+
+func TestPlaceErasure(t *testing.T) {
+	var (
+		hash  = Hash(testKey)
+		nodes = make([]uint64, 12)
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	domain := func(i int) string { return strconv.Itoa(i % 6) }
+
+	data, parity, err := PlaceErasure(nodes, hash, 4, 2, domain)
+	require.NoError(t, err)
+	require.Len(t, data, 4)
+	require.Len(t, parity, 2)
+
+	seen := make(map[string]struct{})
+	for _, i := range append(append([]uint64{}, data...), parity...) {
+		seen[domain(int(i))] = struct{}{}
+	}
+	require.Len(t, seen, 6)
+
+	t.Run("stable roles", func(t *testing.T) {
+		d0, p0, err := PlaceErasure(nodes, hash, 4, 2, nil)
+		require.NoError(t, err)
+
+		// remove the node holding the first parity part
+		var (
+			removed = p0[0]
+			left    = make([]uint64, 0, len(nodes)-1)
+			orig    = make([]int, 0, len(nodes)-1)
+		)
+		for i := range nodes {
+			if uint64(i) != removed {
+				left = append(left, nodes[i])
+				orig = append(orig, i)
+			}
+		}
+
+		d, p, err := PlaceErasure(left, hash, 4, 2, nil)
+		require.NoError(t, err)
+		for i := range d {
+			require.Equal(t, d0[i], uint64(orig[d[i]]))
+		}
+		require.Equal(t, p0[1], uint64(orig[p[1]]))
+	})
+
+	t.Run("not enough domains", func(t *testing.T) {
+		_, _, err := PlaceErasure(nodes, hash, 5, 2, domain)
+		require.Error(t, err)
+	})
+
+	t.Run("not enough nodes", func(t *testing.T) {
+		_, _, err := PlaceErasure(nodes[:3], hash, 2, 2, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("negative counts", func(t *testing.T) {
+		_, _, err := PlaceErasure(nodes, hash, -1, 2, nil)
+		require.Error(t, err)
+		_, _, err = PlaceErasure(nodes, hash, 2, -1, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,79 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+type (
+	// TimeBucketer splits time into windows of the same duration counted
+	// from the Unix epoch, so that data of time series can be sharded by
+	// (series, window) with the owner changing only at window boundaries.
+	TimeBucketer struct {
+		window time.Duration
+	}
+
+	// timeBucketKey is a series prefix followed by a big-endian window number.
+	timeBucketKey []byte
+)
+
+// NewTimeBucketer returns TimeBucketer with the given window duration,
+// which must be positive.
+func NewTimeBucketer(window time.Duration) (*TimeBucketer, error) {
+	if window <= 0 {
+		return nil, errors.New("time bucket window must be positive")
+	}
+	return &TimeBucketer{window: window}, nil
+}
+
+// Window returns the window duration.
+func (b *TimeBucketer) Window() time.Duration { return b.window }
+
+// Bucket returns number of the time window t belongs to.
+func (b *TimeBucketer) Bucket(t time.Time) int64 {
+	ns := t.UnixNano()
+	n := ns / int64(b.window)
+	if ns < 0 && ns%int64(b.window) != 0 {
+		n--
+	}
+	return n
+}
+
+// Start returns the start of the time window t belongs to.
+func (b *TimeBucketer) Start(t time.Time) time.Time {
+	return time.Unix(0, b.Bucket(t)*int64(b.window))
+}
+
+// End returns the end of the time window t belongs to, i.e. the moment
+// owner of the key can change next time.
+func (b *TimeBucketer) End(t time.Time) time.Time {
+	return b.Start(t).Add(b.window)
+}
+
+// Key returns the key composed from prefix (e.g. time-series ID) and number
+// of the time window t belongs to, it can be used with SortSliceByValue and
+// alike.
+func (b *TimeBucketer) Key(prefix []byte, t time.Time) Hasher {
+	key := make(timeBucketKey, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(b.Bucket(t)))
+	return key
+}
+
+// Hash returns hash of the key returned by Key.
+func (b *TimeBucketer) Hash(prefix []byte, t time.Time) uint64 {
+	return b.Key(prefix, t).Hash()
+}
+
+// Owner returns index of the node owning data from prefix series written
+// in the time window t belongs to or -1 if there are no nodes.
+func (b *TimeBucketer) Owner(nodes []uint64, prefix []byte, t time.Time) int {
+	if len(nodes) == 0 {
+		return -1
+	}
+	return closest(nodes, b.Hash(prefix, t))
+}
+
+// Hash implements Hasher interface.
+func (k timeBucketKey) Hash() uint64 { return Hash(k) }
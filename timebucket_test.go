@@ -0,0 +1,84 @@
+package hrw
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeBucketer(t *testing.T) {
+	const window = time.Hour
+	var (
+		start = time.Unix(1600002000, 0).Truncate(window)
+		mid   = start.Add(30 * time.Minute)
+	)
+
+	b, err := NewTimeBucketer(window)
+	require.NoError(t, err)
+	require.Equal(t, window, b.Window())
+
+	require.Equal(t, b.Bucket(start), b.Bucket(mid))
+	require.Equal(t, b.Bucket(start)+1, b.Bucket(start.Add(window)))
+	require.True(t, b.Start(mid).Equal(start))
+	require.True(t, b.End(mid).Equal(start.Add(window)))
+
+	t.Run("before epoch", func(t *testing.T) {
+		require.Equal(t, int64(-1), b.Bucket(time.Unix(-1, 0)))
+		require.Equal(t, int64(-1), b.Bucket(time.Unix(-3600, 0)))
+		require.Equal(t, int64(-2), b.Bucket(time.Unix(-3601, 0)))
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		_, err := NewTimeBucketer(0)
+		require.Error(t, err)
+		_, err = NewTimeBucketer(-time.Second)
+		require.Error(t, err)
+	})
+}
+
+func TestTimeBucketerKey(t *testing.T) {
+	var (
+		prefix = []byte("cpu.load{host=a}")
+		start  = time.Unix(1600002000, 0).Truncate(time.Minute)
+	)
+	b, err := NewTimeBucketer(time.Minute)
+	require.NoError(t, err)
+
+	h := b.Hash(prefix, start)
+	require.Equal(t, h, b.Key(prefix, start).Hash())
+	require.Equal(t, h, b.Hash(prefix, start.Add(time.Minute-1)))
+	require.NotEqual(t, h, b.Hash(prefix, start.Add(time.Minute)))
+	require.NotEqual(t, h, b.Hash([]byte("cpu.load{host=b}"), start))
+
+	// key is the prefix followed by the big-endian window number
+	key := append(append([]byte{}, prefix...), 0, 0, 0, 0, 0x01, 0x96, 0xe6, 0xcc)
+	require.Equal(t, int64(0x0196e6cc), b.Bucket(start))
+	require.Equal(t, Hash(key), h)
+}
+
+func TestTimeBucketerOwner(t *testing.T) {
+	const window = time.Minute
+	var (
+		prefix = []byte("series")
+		nodes  = make([]uint64, 8)
+		start  = time.Unix(1600002000, 0).Truncate(window)
+		owners = make(map[int]struct{})
+	)
+	for i := range nodes {
+		nodes[i] = Hash([]byte(strconv.Itoa(i)))
+	}
+	b, err := NewTimeBucketer(window)
+	require.NoError(t, err)
+
+	for i := 0; i < 32; i++ {
+		ts := start.Add(time.Duration(i) * window)
+		o := b.Owner(nodes, prefix, ts)
+		require.Equal(t, o, b.Owner(nodes, prefix, ts.Add(window/2)))
+		require.Equal(t, Sort(nodes, b.Hash(prefix, ts))[0], uint64(o))
+		owners[o] = struct{}{}
+	}
+	require.True(t, len(owners) > 1)
+	require.Equal(t, -1, b.Owner(nil, prefix, start))
+}
@@ -0,0 +1,163 @@
+package hrw
+
+import "container/heap"
+
+// indexHeap is a heap of node indices ordered by less.
+type indexHeap struct {
+	ind  []int
+	less func(i, j int) bool
+}
+
+func (h *indexHeap) Len() int           { return len(h.ind) }
+func (h *indexHeap) Less(i, j int) bool { return h.less(h.ind[i], h.ind[j]) }
+func (h *indexHeap) Swap(i, j int)      { h.ind[i], h.ind[j] = h.ind[j], h.ind[i] }
+func (h *indexHeap) Push(x interface{}) { h.ind = append(h.ind, x.(int)) }
+func (h *indexHeap) Pop() interface{} {
+	x := h.ind[len(h.ind)-1]
+	h.ind = h.ind[:len(h.ind)-1]
+	return x
+}
+
+// TopN returns indices of the first n nodes in the order of Sort without
+// sorting all of them, which is O(len(nodes) * log(n)). Negative n is
+// treated as 0, n greater than the number of nodes as the number of nodes.
+func TopN(nodes []uint64, hash uint64, n int) []uint64 {
+	dist := distances(len(nodes), false, nodes, hash)
+	return toUint64s(topOrder(len(dist), n, func(i, j int) bool {
+		return dist[i] < dist[j]
+	}))
+}
+
+// TopNByWeight returns indices of the first n nodes in the order of
+// SortByWeight, see TopN.
+func TopNByWeight(nodes []uint64, weights []float64, hash uint64, n int) []uint64 {
+	dist := distances(len(nodes), false, nodes, hash)
+	return toUint64s(topOrder(len(dist), n, weightLess(dist, weights)))
+}
+
+// weightLess returns ordering of node indices by weighted scores of
+// precomputed distances, see weightOrder.
+func weightLess(dist []uint64, weights []float64) func(i, j int) bool {
+	if allSameF64(weights) {
+		return func(i, j int) bool { return dist[i] < dist[j] }
+	}
+	return func(i, j int) bool {
+		return compareWeighted(dist[i], dist[j], weights[i], weights[j]) < 0
+	}
+}
+
+// topOrder returns first n of l indices ordered by less.
+func topOrder(l, n int, less func(i, j int) bool) []int {
+	if n < 0 {
+		n = 0
+	} else if n > l {
+		n = l
+	}
+
+	// the worst of n best indices is kept at the root
+	h := &indexHeap{ind: make([]int, 0, n), less: func(i, j int) bool { return less(j, i) }}
+	for i := 0; i < l && n > 0; i++ {
+		if h.Len() < n {
+			heap.Push(h, i)
+		} else if less(i, h.ind[0]) {
+			h.ind[0] = i
+			heap.Fix(h, 0)
+		}
+	}
+
+	res := make([]int, n)
+	for i := n - 1; i >= 0; i-- {
+		res[i] = heap.Pop(h).(int)
+	}
+	return res
+}
+
+// Rank returns position of the i-th node in the order of Sort, counting
+// nodes with shorter distances in a single pass without sorting.
+func Rank(nodes []uint64, hash uint64, i int) int {
+	d := distance(nodes[i], hash)
+	rank := 0
+	for j := range nodes {
+		if distance(nodes[j], hash) < d {
+			rank++
+		}
+	}
+	return rank
+}
+
+// RankByWeight returns position of the i-th node in the order of
+// SortByWeight, see Rank.
+func RankByWeight(nodes []uint64, weights []float64, hash uint64, i int) int {
+	var (
+		dist = distances(len(nodes), false, nodes, hash)
+		less = weightLess(dist, weights)
+		rank = 0
+	)
+	for j := range nodes {
+		if less(j, i) {
+			rank++
+		}
+	}
+	return rank
+}
+
+// IsTopN reports whether the i-th node is among the first n nodes in the
+// order of Sort. It stops counting nodes with shorter distances as soon as
+// there are n of them.
+func IsTopN(nodes []uint64, hash uint64, i, n int) bool {
+	d := distance(nodes[i], hash)
+	for j := range nodes {
+		if n <= 0 {
+			return false
+		}
+		if distance(nodes[j], hash) < d {
+			n--
+		}
+	}
+	return n > 0
+}
+
+// IsTopNByWeight reports whether the i-th node is among the first n nodes
+// in the order of SortByWeight, see IsTopN.
+func IsTopNByWeight(nodes []uint64, weights []float64, hash uint64, i, n int) bool {
+	less := weightLess(distances(len(nodes), false, nodes, hash), weights)
+	for j := range nodes {
+		if n <= 0 {
+			return false
+		}
+		if less(j, i) {
+			n--
+		}
+	}
+	return n > 0
+}
+
+// Pick returns index of the first node in the order of Sort in a single
+// pass without allocations. It returns false if there are no nodes.
+func Pick(nodes []uint64, hash uint64) (uint64, bool) {
+	if len(nodes) == 0 {
+		return 0, false
+	}
+	best, bestDist := 0, distance(nodes[0], hash)
+	for i := 1; i < len(nodes); i++ {
+		if d := distance(nodes[i], hash); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return uint64(best), true
+}
+
+// PickByWeight returns index of the first node in the order of
+// SortByWeight, see Pick.
+func PickByWeight(nodes []uint64, weights []float64, hash uint64) (uint64, bool) {
+	if allSameF64(weights) {
+		return Pick(nodes, hash)
+	}
+	best, bestDist := 0, distance(nodes[0], hash)
+	for i := 1; i < len(nodes); i++ {
+		if d := distance(nodes[i], hash); compareWeighted(d, bestDist, weights[i], weights[best]) < 0 {
+			best, bestDist = i, d
+		}
+	}
+	return uint64(best), true
+}
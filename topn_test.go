@@ -0,0 +1,146 @@
+package hrw
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopN(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 100)
+		weights = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for k := uint64(0); k < 100; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		for _, n := range []int{1, 3, 10, len(nodes)} {
+			require.Equal(t, Sort(nodes, hash)[:n], TopN(nodes, hash, n))
+			require.Equal(t, SortByWeight(nodes, weights, hash)[:n], TopNByWeight(nodes, weights, hash, n))
+		}
+	}
+
+	t.Run("same weights", func(t *testing.T) {
+		hash := Hash(testKey)
+		same := make([]float64, len(nodes))
+		require.Equal(t, Sort(nodes, hash)[:5], TopNByWeight(nodes, same, hash, 5))
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		hash := Hash(testKey)
+		require.Empty(t, TopN(nodes, hash, 0))
+		require.Empty(t, TopN(nodes, hash, -1))
+		require.Equal(t, Sort(nodes, hash), TopN(nodes, hash, len(nodes)+1))
+		require.Empty(t, TopN(nil, hash, 3))
+	})
+}
+
+func TestRank(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 20)
+		weights = make([]float64, len(nodes))
+		same    = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for k := uint64(0); k < 20; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		for pos, i := range Sort(nodes, hash) {
+			require.Equal(t, pos, Rank(nodes, hash, int(i)))
+			require.Equal(t, pos, RankByWeight(nodes, same, hash, int(i)))
+		}
+		for pos, i := range SortByWeight(nodes, weights, hash) {
+			require.Equal(t, pos, RankByWeight(nodes, weights, hash, int(i)))
+		}
+	}
+}
+
+func TestIsTopN(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 20)
+		weights = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for k := uint64(0); k < 20; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		for _, n := range []int{-1, 0, 1, 3, len(nodes), len(nodes) + 1} {
+			for i := range nodes {
+				require.Equal(t, Rank(nodes, hash, i) < n, IsTopN(nodes, hash, i, n))
+				require.Equal(t, RankByWeight(nodes, weights, hash, i) < n, IsTopNByWeight(nodes, weights, hash, i, n))
+			}
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	var (
+		nodes   = make([]uint64, 20)
+		weights = make([]float64, len(nodes))
+		key     = make([]byte, 8)
+	)
+	for i := range nodes {
+		nodes[i] = rand.Uint64()
+		weights[i] = rand.Float64()
+	}
+
+	for k := uint64(0); k < 100; k++ {
+		binary.BigEndian.PutUint64(key, k)
+		hash := Hash(key)
+
+		i, ok := Pick(nodes, hash)
+		require.True(t, ok)
+		require.Equal(t, Sort(nodes, hash)[0], i)
+
+		i, ok = PickByWeight(nodes, weights, hash)
+		require.True(t, ok)
+		require.Equal(t, SortByWeight(nodes, weights, hash)[0], i)
+	}
+
+	_, ok := Pick(nil, 1)
+	require.False(t, ok)
+	_, ok = PickByWeight(nil, nil, 1)
+	require.False(t, ok)
+
+	hash := Hash(testKey)
+	require.Zero(t, testing.AllocsPerRun(10, func() { Pick(nodes, hash) }))
+	require.Zero(t, testing.AllocsPerRun(10, func() { PickByWeight(nodes, weights, hash) }))
+}
+
+func BenchmarkTopN_3_1000(b *testing.B) {
+	var (
+		hash    = Hash(testKey)
+		servers = make([]uint64, 1000)
+	)
+	for i := range servers {
+		servers[i] = uint64(i)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_ = TopN(servers, hash, 3)
+	}
+}